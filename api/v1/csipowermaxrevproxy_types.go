@@ -68,6 +68,44 @@ type RevProxyConfig struct {
 	StandAloneConfig *StandAloneConfig `json:"standAloneConfig,omitempty" yaml:"standAloneConfig,omitempty"`
 }
 
+// ProxyTLSMode - Mode in which the TLS certificate of the proxy is provisioned
+type ProxyTLSMode string
+
+const (
+	// ProxyTLSModeProvided - The TLS secret of the proxy is created by the user
+	ProxyTLSModeProvided ProxyTLSMode = "Provided"
+	// ProxyTLSModeSelfSigned - The operator generates a CA & a serving certificate signed by it
+	ProxyTLSModeSelfSigned ProxyTLSMode = "SelfSigned"
+	// ProxyTLSModeCertManager - The operator creates a cert-manager Certificate which issues the TLS secret
+	ProxyTLSModeCertManager ProxyTLSMode = "CertManager"
+)
+
+// CertManagerIssuerRef - Reference to the cert-manager issuer of the certificate of the proxy
+type CertManagerIssuerRef struct {
+	// Name of the issuer
+	Name string `json:"name" yaml:"name"`
+	// Kind of the issuer. Can be Issuer (default) or ClusterIssuer
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+}
+
+// ProxyTLSConfig - Configuration of the TLS certificate of the proxy
+// In the SelfSigned mode, the CA & its private key are stored in the powermax-reverseproxy-ca secret, which must not
+// be shared. The certificate of the CA is published alone in the powermax-reverseproxy-ca-cert secret (key ca.crt),
+// which can be added to the certSecrets of the CSIPowerMax driver
+type ProxyTLSConfig struct {
+	// Mode can be Provided (default), SelfSigned or CertManager
+	// +kubebuilder:validation:Enum=Provided;SelfSigned;CertManager
+	Mode ProxyTLSMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Duration is the validity of the serving certificate. Defaults to 8760h
+	Duration *metav1.Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+	// RenewBefore is the time before the expiry of the serving certificate at which it is renewed. Defaults to 720h
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty" yaml:"renewBefore,omitempty"`
+	// IssuerRef is the cert-manager issuer of the certificate. Required in the CertManager mode
+	IssuerRef *CertManagerIssuerRef `json:"issuerRef,omitempty" yaml:"issuerRef,omitempty"`
+}
+
 // CSIPowerMaxRevProxySpec defines the desired state of CSIPowerMaxRevProxy
 type CSIPowerMaxRevProxySpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -75,8 +113,18 @@ type CSIPowerMaxRevProxySpec struct {
 
 	Image           string            `json:"image" yaml:"image"`
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
-	TLSSecret       string            `json:"tlsSecret" yaml:"tlsSecret"`
-	RevProxy        RevProxyConfig    `json:"config" yaml:"config"`
+	// TLSSecret is the secret holding the TLS certificate of the proxy. Required in the Provided TLS mode
+	// Defaults to powermax-reverseproxy-tls in the SelfSigned & CertManager TLS modes
+	TLSSecret string         `json:"tlsSecret,omitempty" yaml:"tlsSecret"`
+	RevProxy  RevProxyConfig `json:"config" yaml:"config"`
+	// TLS is the configuration of the TLS certificate of the proxy
+	TLS *ProxyTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// TrustBundle is a bundle of CA certificates used by the proxy to validate the certificates of the
+	// management servers. Can be used instead of the cert secrets of the management servers
+	TrustBundle *TrustBundle `json:"trustBundle,omitempty" yaml:"trustBundle,omitempty"`
+	// CertExpiryThresholds are the times before the expiry of a certificate used by the proxy at which
+	// a warning is reported. Defaults to 720h, 168h & 24h
+	CertExpiryThresholds []metav1.Duration `json:"certExpiryThresholds,omitempty" yaml:"certExpiryThresholds,omitempty"`
 }
 
 // CSIPowerMaxRevProxyStatus defines the observed state of CSIPowerMaxRevProxy
@@ -106,6 +154,17 @@ type CSIPowerMaxRevProxyStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="LastUpdate"
 	LastUpdate LastUpdate `json:"lastUpdate,omitempty" yaml:"lastUpdate"`
+
+	// CertificateExpiry is the earliest expiring certificate used by the proxy
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="CertificateExpiry"
+	CertificateExpiry *CertificateExpiry `json:"certificateExpiry,omitempty" yaml:"certificateExpiry"`
+
+	// Conditions is the list of conditions recorded by the operator for the proxy
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="Conditions"
+	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.x-descriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions"`
 }
 
 // +kubebuilder:object:root=true
@@ -17,11 +17,13 @@ package v1
 import (
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +k8s:deepcopy-gen=false
@@ -444,6 +446,19 @@ const (
 	MetadataRetriever = "csi-metadata-retriever"
 )
 
+// UpgradePolicy - type representing the upgrade policy of the driver
+type UpgradePolicy string
+
+// Constants for the upgrade policies
+const (
+	// UpgradePolicyManual - config version is only changed by the user
+	UpgradePolicyManual UpgradePolicy = "Manual"
+	// UpgradePolicyAutoPatch - operator moves the driver to newer patch versions
+	UpgradePolicyAutoPatch UpgradePolicy = "AutoPatch"
+	// UpgradePolicyAutoMinor - operator moves the driver to newer minor & patch versions
+	UpgradePolicyAutoMinor UpgradePolicy = "AutoMinor"
+)
+
 // InitContainerType - type representing type of initcontainer
 type InitContainerType string
 
@@ -463,6 +478,8 @@ type PodSchedulingConstraints struct {
 type Driver struct {
 
 	// ConfigVersion is the configuration version of the driver
+	// A channel alias (e.g. latest, stable, n-1) can be specified to follow the config versions of the channel
+	// through the upgrade paths
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Config Version"
 	ConfigVersion string `json:"configVersion" yaml:"configVersion"`
 
@@ -492,7 +509,7 @@ type Driver struct {
 
 	// Node is the specification for Node plugin only
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Node specification"
-	Node ContainerTemplate `json:"node,omitempty" yaml:"node"`
+	Node NodeTemplate `json:"node,omitempty" yaml:"node"`
 
 	// SideCars is the specification for CSI sidecar containers
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="CSI SideCars specification"
@@ -512,9 +529,19 @@ type Driver struct {
 	SnapshotClass []SnapshotClass `json:"snapshotClass,omitempty" yaml:"snapshotClass"`
 
 	// ForceUpdate is the boolean flag used to force an update of the driver instance
+	// Deprecated: Use RestartNonce instead. Setting this flag is the same as setting RestartNonce to the current time
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Force update"
 	ForceUpdate bool `json:"forceUpdate,omitempty" yaml:"forceUpdate"`
 
+	// RestartNonce is an opaque string (e.g. a timestamp). Any change to it restarts the driver pods
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Restart Nonce"
+	RestartNonce string `json:"restartNonce,omitempty" yaml:"restartNonce"`
+
+	// DisableSecretRollout disables the rolling restart of the driver pods when the content of a Secret
+	// referenced by them (e.g. credentials, certificates) changes
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Disable Secret Rollout"
+	DisableSecretRollout bool `json:"disableSecretRollout,omitempty" yaml:"disableSecretRollout"`
+
 	// AuthSecret is the name of the credentials secret for the driver
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auth Secret"
 	AuthSecret string `json:"authSecret,omitempty" yaml:"authSecret"`
@@ -522,6 +549,105 @@ type Driver struct {
 	// TLSCertSecret is the name of the TLS Cert secret
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="TLSCert Secret"
 	TLSCertSecret string `json:"tlsCertSecret,omitempty" yaml:"tlsCertSecret"`
+
+	// CertSecretSelector selects the Secrets holding the certificates of the storage arrays by their labels
+	// The certificates are mounted in the certs volume of the driver & certificate validation is enabled
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Cert Secret Selector"
+	CertSecretSelector *metav1.LabelSelector `json:"certSecretSelector,omitempty" yaml:"certSecretSelector"`
+
+	// CertSecrets is the list of names of the Secrets holding the certificates of the storage arrays
+	// Can be used along with CertSecretSelector
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Cert Secrets"
+	CertSecrets []string `json:"certSecrets,omitempty" yaml:"certSecrets"`
+
+	// TrustBundle is a bundle of CA certificates used to validate the certificates of the storage arrays
+	// The operator converts it to the certificate secret expected by the driver & keeps it in sync
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Bundle"
+	TrustBundle *TrustBundle `json:"trustBundle,omitempty" yaml:"trustBundle"`
+
+	// CertExpiryThresholds are the times before the expiry of a certificate mounted in the driver pods at which
+	// a warning is reported. Defaults to 720h, 168h & 24h
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Certificate Expiry Thresholds"
+	CertExpiryThresholds []metav1.Duration `json:"certExpiryThresholds,omitempty" yaml:"certExpiryThresholds"`
+
+	// UpgradePolicy is the policy used by the operator to move the driver to newer config versions
+	// Valid values are Manual (default), AutoPatch and AutoMinor
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upgrade Policy"
+	UpgradePolicy UpgradePolicy `json:"upgradePolicy,omitempty" yaml:"upgradePolicy"`
+
+	// MaintenanceWindows is the list of windows during which changes to the driver specification are applied
+	// Changes made outside of a window are held back until the next window starts
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Maintenance Windows"
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty" yaml:"maintenanceWindows"`
+
+	// RetryPolicy controls how the operator retries the deployment of the driver after a failure
+	// Overrides the retry policy configured for the operator
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Retry Policy"
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty" yaml:"retryPolicy"`
+
+	// CHAP configures the iSCSI CHAP authentication of the Node plugin (PowerMax only)
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="CHAP"
+	CHAP *CHAPConfig `json:"chap,omitempty" yaml:"chap"`
+}
+
+// CHAPConfig - iSCSI CHAP authentication settings of the Node plugin
+// +k8s:openapi-gen=true
+type CHAPConfig struct {
+	// AutoGenerate enables CHAP without a manually created CHAP secret
+	// For PowerMax, the operator generates a random CHAP secret into a Secret owned by the driver & rotates it
+	// whenever the storage.dell.com/rotate-chap annotation of the CR changes
+	// It isn't supported for PowerStore, whose driver generates the CHAP credentials itself when
+	// X_CSI_POWERSTORE_ENABLE_CHAP is true
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auto Generate"
+	AutoGenerate bool `json:"autoGenerate,omitempty" yaml:"autoGenerate"`
+}
+
+// RetryPolicy - Policy used to retry the deployment of the driver after a failure
+// +k8s:openapi-gen=true
+type RetryPolicy struct {
+	// Interval is the initial interval between the retries. Doubled after every retry
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Interval"
+	Interval *metav1.Duration `json:"interval,omitempty" yaml:"interval"`
+
+	// MaxDuration is the duration after which the operator stops retrying & marks the driver as Failed
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Duration"
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty" yaml:"maxDuration"`
+}
+
+// TrustBundle - Reference to a key of a ConfigMap or a Secret holding PEM encoded CA certificates
+// OpenShift's trusted CA bundle can be used by setting injectTrustedCABundle & referencing the key
+// ca-bundle.crt of a ConfigMap
+// +k8s:openapi-gen=true
+type TrustBundle struct {
+	// ConfigMapKeyRef selects the key of a ConfigMap in the namespace of the CR holding the bundle
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="ConfigMap Key Reference"
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty" yaml:"configMapKeyRef"`
+
+	// SecretKeyRef selects the key of a Secret in the namespace of the CR holding the bundle
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Secret Key Reference"
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty" yaml:"secretKeyRef"`
+
+	// InjectTrustedCABundle makes the operator create the ConfigMap referenced by configMapKeyRef (if it doesn't
+	// exist) labeled with config.openshift.io/inject-trusted-cabundle=true, so that OpenShift injects its trusted
+	// CA bundle in the key ca-bundle.crt of the ConfigMap
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Inject Trusted CA Bundle"
+	InjectTrustedCABundle bool `json:"injectTrustedCABundle,omitempty" yaml:"injectTrustedCABundle"`
+}
+
+// MaintenanceWindow - Recurring window during which driver changes can be applied
+// +k8s:openapi-gen=true
+type MaintenanceWindow struct {
+	// Schedule is a cron expression (minute hour day-of-month month day-of-week) for the start of the window
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Schedule"
+	Schedule string `json:"schedule" yaml:"schedule"`
+
+	// Duration is the length of the window
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Duration"
+	Duration metav1.Duration `json:"duration" yaml:"duration"`
+
+	// TimeZone is the IANA time zone in which the schedule is evaluated. Defaults to UTC
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Time Zone"
+	TimeZone string `json:"timeZone,omitempty" yaml:"timeZone"`
 }
 
 // ContainerTemplate - Structure representing a container
@@ -558,6 +684,71 @@ type ContainerTemplate struct {
 	NodeSelector map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector"`
 }
 
+// NodeTemplate - Structure representing the Node plugin
+// +k8s:openapi-gen=true
+type NodeTemplate struct {
+	ContainerTemplate `json:",inline" yaml:",inline"`
+
+	// UpdateStrategy is the update strategy of the daemonset for Node plugin
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Update Strategy"
+	UpdateStrategy *NodeUpdateStrategy `json:"updateStrategy,omitempty" yaml:"updateStrategy"`
+
+	// Canary is the specification for a canary rollout of the Node plugin
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Canary"
+	Canary *NodeCanary `json:"canary,omitempty" yaml:"canary"`
+}
+
+// NodeCanary - Canary rollout of the Node plugin to a subset of nodes
+// +k8s:openapi-gen=true
+type NodeCanary struct {
+	// NodeSelector selects the nodes on which the canary Node plugin is run
+	// These nodes are excluded from the main daemonset
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Canary NodeSelector"
+	NodeSelector map[string]string `json:"nodeSelector" yaml:"nodeSelector"`
+
+	// Image is the driver image used by the canary Node plugin
+	// Defaults to the driver image of the canary config version (if specified) or the driver
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Canary Image"
+	Image string `json:"image,omitempty" yaml:"image"`
+
+	// ConfigVersion is the configuration version used by the canary Node plugin
+	// Defaults to the config version of the driver
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Canary Config Version"
+	ConfigVersion string `json:"configVersion,omitempty" yaml:"configVersion"`
+
+	// Promote folds the canary image & config version into the driver spec and removes the canary
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Promote Canary"
+	Promote bool `json:"promote,omitempty" yaml:"promote"`
+}
+
+// NodeUpdateStrategyVolumeAware - Update strategy where the operator restarts the node pods in batches,
+// starting with the nodes which have the least number of volumes attached
+const NodeUpdateStrategyVolumeAware appsv1.DaemonSetUpdateStrategyType = "VolumeAware"
+
+// NodeUpdateStrategy - Update strategy for the daemonset of the Node plugin
+// +k8s:openapi-gen=true
+type NodeUpdateStrategy struct {
+	// Type of the update strategy. Can be RollingUpdate (default), OnDelete or VolumeAware
+	// +kubebuilder:validation:Enum=RollingUpdate;OnDelete;VolumeAware
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Update Strategy Type"
+	Type appsv1.DaemonSetUpdateStrategyType `json:"type,omitempty" yaml:"type"`
+
+	// MaxUnavailable is the maximum number (or percentage) of node pods that can be unavailable during the update
+	// For the VolumeAware update strategy, this is the number of node pods restarted in each batch
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Unavailable"
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty" yaml:"maxUnavailable"`
+
+	// Pause is the time to wait between batches of node pods
+	// This is only applicable to the VolumeAware update strategy
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pause"
+	Pause *metav1.Duration `json:"pause,omitempty" yaml:"pause"`
+
+	// MaxSurge is the maximum number (or percentage) of nodes with an existing node pod
+	// that can have an updated node pod during the update
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Surge"
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty" yaml:"maxSurge"`
+}
+
 // ImageType - represents type of image
 type ImageType string
 
@@ -608,6 +799,194 @@ type DriverStatus struct {
 	// LastUpdate is the last updated state of the driver
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="LastUpdate"
 	LastUpdate LastUpdate `json:"lastUpdate,omitempty" yaml:"lastUpdate"`
+
+	// AvailableUpgrades is the list of config versions the driver can be upgraded to on this K8s version
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="AvailableUpgrades"
+	AvailableUpgrades []string `json:"availableUpgrades,omitempty" yaml:"availableUpgrades"`
+
+	// ResolvedConfigVersion is the config version the channel alias in the driver spec resolved to
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="ResolvedConfigVersion",xDescriptors="urn:alm:descriptor:text"
+	ResolvedConfigVersion string `json:"resolvedConfigVersion,omitempty" yaml:"resolvedConfigVersion"`
+
+	// Revision is the latest revision of the driver spec stored in the revision history
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Revision",xDescriptors="urn:alm:descriptor:text"
+	Revision int64 `json:"revision,omitempty" yaml:"revision"`
+
+	// NodeRollout is the progress of the operator managed rollout of the Node plugin
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="NodeRollout"
+	NodeRollout *NodeRolloutStatus `json:"nodeRollout,omitempty" yaml:"nodeRollout"`
+
+	// RestartNonce is the last restart nonce applied to the driver pods
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="RestartNonce",xDescriptors="urn:alm:descriptor:text"
+	RestartNonce string `json:"restartNonce,omitempty" yaml:"restartNonce"`
+
+	// Rollout is the progress of the rollout of the driver specification to the driver pods
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Rollout"
+	Rollout *RolloutStatus `json:"rollout,omitempty" yaml:"rollout"`
+
+	// PendingUpdate is a change to the driver specification held back until the next maintenance window
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="PendingUpdate"
+	PendingUpdate *PendingUpdate `json:"pendingUpdate,omitempty" yaml:"pendingUpdate"`
+
+	// CertificateExpiry is the earliest expiring certificate mounted in the driver pods
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="CertificateExpiry"
+	CertificateExpiry *CertificateExpiry `json:"certificateExpiry,omitempty" yaml:"certificateExpiry"`
+
+	// MissingDependencies is the list of objects required by the driver which don't exist yet
+	// The driver is reconciled again once they are created
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="MissingDependencies"
+	MissingDependencies []Dependency `json:"missingDependencies,omitempty" yaml:"missingDependencies"`
+
+	// StorageArrays is the list of storage arrays configured in the config secret of the driver
+	// It is cleared when the config is invalid
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="StorageArrays"
+	StorageArrays []StorageArrayStatus `json:"storageArrays,omitempty" yaml:"storageArrays"`
+
+	// Conditions is the list of conditions recorded by the operator for the driver
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions"`
+}
+
+// CertificateExpiry - Certificate found in one of the Secrets mounted in the pods along with its expiry
+// +k8s:openapi-gen=true
+type CertificateExpiry struct {
+	// Secret is the name of the Secret holding the certificate
+	Secret string `json:"secret" yaml:"secret"`
+
+	// Key is the key of the Secret holding the certificate
+	Key string `json:"key" yaml:"key"`
+
+	// Subject is the subject of the certificate
+	Subject string `json:"subject,omitempty" yaml:"subject"`
+
+	// NotAfter is the time at which the certificate expires
+	NotAfter metav1.Time `json:"notAfter" yaml:"notAfter"`
+
+	// Threshold is the smallest of the expiry thresholds crossed by the certificate (0 once it has expired)
+	Threshold *metav1.Duration `json:"threshold,omitempty" yaml:"threshold"`
+}
+
+// Dependency - Reference to an object in the namespace of the driver
+// +k8s:openapi-gen=true
+type Dependency struct {
+	// Kind is the kind of the object (Secret or ConfigMap)
+	Kind string `json:"kind" yaml:"kind"`
+
+	// Name is the name of the object
+	Name string `json:"name" yaml:"name"`
+}
+
+// StorageArrayStatus - Storage array configured in the config secret of the driver
+// +k8s:openapi-gen=true
+type StorageArrayStatus struct {
+	// ID is the identifier of the storage array in the config secret (e.g. the globalID of a PowerStore array
+	// or the clusterName of a PowerScale cluster)
+	ID string `json:"id" yaml:"id"`
+
+	// Endpoint is the endpoint of the management API of the storage array
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint"`
+
+	// IsDefault is true for the default storage array of the driver
+	IsDefault bool `json:"isDefault,omitempty" yaml:"isDefault"`
+}
+
+// NodeRolloutState - Type representing the state of the operator managed rollout of the Node plugin
+type NodeRolloutState string
+
+// Constants for the states of the operator managed rollout of the Node plugin
+const (
+	NodeRolloutInProgress NodeRolloutState = "InProgress"
+	NodeRolloutCompleted  NodeRolloutState = "Completed"
+	NodeRolloutHalted     NodeRolloutState = "Halted"
+)
+
+// NodeRolloutStatus - Progress of the operator managed rollout of the Node plugin
+// +k8s:openapi-gen=true
+type NodeRolloutStatus struct {
+	// State is the state of the rollout
+	State NodeRolloutState `json:"state,omitempty" yaml:"state"`
+
+	// TemplateChecksum is the checksum of the pod template being rolled out
+	TemplateChecksum string `json:"templateChecksum,omitempty" yaml:"templateChecksum"`
+
+	// UpdatedPods is the number of node pods running the pod template being rolled out
+	UpdatedPods int32 `json:"updatedPods" yaml:"updatedPods"`
+
+	// TotalPods is the total number of node pods
+	TotalPods int32 `json:"totalPods" yaml:"totalPods"`
+
+	// CurrentBatch is the list of nodes on which the node pods were last restarted
+	CurrentBatch []string `json:"currentBatch,omitempty" yaml:"currentBatch"`
+
+	// LastBatchTime is the time at which the last batch of node pods was restarted
+	LastBatchTime *metav1.Time `json:"lastBatchTime,omitempty" yaml:"lastBatchTime"`
+
+	// Message is a human readable description of the rollout
+	Message string `json:"message,omitempty" yaml:"message"`
+}
+
+// RolloutStatus - Progress of the rollout of the driver specification to the driver pods
+// +k8s:openapi-gen=true
+type RolloutStatus struct {
+	// TargetDriverHash is the hash of the driver specification being rolled out
+	TargetDriverHash uint64 `json:"targetDriverHash,omitempty" yaml:"targetDriverHash"`
+
+	// CurrentDriverHash is the hash of the last driver specification which was completely rolled out
+	CurrentDriverHash uint64 `json:"currentDriverHash,omitempty" yaml:"currentDriverHash"`
+
+	// StartTime is the time at which the rollout of the target driver specification started
+	StartTime *metav1.Time `json:"startTime,omitempty" yaml:"startTime"`
+
+	// Progress is the percentage of driver pods which are updated & available
+	Progress int32 `json:"progress" yaml:"progress"`
+
+	// Controller is the rollout status of the Controller plugin
+	Controller ControllerRolloutStatus `json:"controller,omitempty" yaml:"controller"`
+
+	// Node is the rollout status of the Node plugin
+	Node NodeWorkloadRolloutStatus `json:"node,omitempty" yaml:"node"`
+
+	// Canary is the rollout status of the canary Node plugin (if requested)
+	Canary *NodeWorkloadRolloutStatus `json:"canary,omitempty" yaml:"canary"`
+}
+
+// ControllerRolloutStatus - Rollout status of the Deployment/StatefulSet of the Controller plugin
+// +k8s:openapi-gen=true
+type ControllerRolloutStatus struct {
+	// Replicas is the desired number of controller pods
+	Replicas int32 `json:"replicas" yaml:"replicas"`
+
+	// UpdatedReplicas is the number of controller pods running the latest pod template
+	UpdatedReplicas int32 `json:"updatedReplicas" yaml:"updatedReplicas"`
+
+	// AvailableReplicas is the number of available controller pods
+	AvailableReplicas int32 `json:"availableReplicas" yaml:"availableReplicas"`
+}
+
+// NodeWorkloadRolloutStatus - Rollout status of the DaemonSet of the Node plugin
+// +k8s:openapi-gen=true
+type NodeWorkloadRolloutStatus struct {
+	// DesiredNumberScheduled is the desired number of node pods
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled" yaml:"desiredNumberScheduled"`
+
+	// UpdatedNumberScheduled is the number of node pods running the latest pod template
+	UpdatedNumberScheduled int32 `json:"updatedNumberScheduled" yaml:"updatedNumberScheduled"`
+
+	// NumberAvailable is the number of available node pods
+	NumberAvailable int32 `json:"numberAvailable" yaml:"numberAvailable"`
+}
+
+// PendingUpdate - Change to the driver specification waiting for a maintenance window
+// +k8s:openapi-gen=true
+type PendingUpdate struct {
+	// DriverHash is the hash of the driver specification which will be applied
+	DriverHash uint64 `json:"driverHash,omitempty" yaml:"driverHash"`
+
+	// NextWindow is the start time of the next maintenance window
+	NextWindow *metav1.Time `json:"nextWindow,omitempty" yaml:"nextWindow"`
+
+	// Message is a human readable description of the pending update
+	Message string `json:"message,omitempty" yaml:"message"`
 }
 
 // LastUpdate - Stores the last update condition for the driver status
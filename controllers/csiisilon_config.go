@@ -0,0 +1,163 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// isilonClusterConfig - PowerScale cluster in the credentials secret of the Isilon driver
+type isilonClusterConfig struct {
+	ClusterName               string              `json:"clusterName"`
+	Username                  string              `json:"username"`
+	Password                  string              `json:"password"`
+	Endpoint                  string              `json:"endpoint"`
+	EndpointPort              *intstr.IntOrString `json:"endpointPort,omitempty"`
+	IsDefault                 bool                `json:"isDefault,omitempty"`
+	SkipCertificateValidation *bool               `json:"skipCertificateValidation,omitempty"`
+	IsiPath                   string              `json:"isiPath,omitempty"`
+	IsiVolumePathPermissions  string              `json:"isiVolumePathPermissions,omitempty"`
+}
+
+// isilonConfig - Content of the config key of the credentials secret of the Isilon driver
+type isilonConfig struct {
+	IsilonClusters []isilonClusterConfig `json:"isilonClusters"`
+}
+
+// getIsilonCredsSecretName - Returns the name of the credentials secret of the Isilon driver
+func getIsilonCredsSecretName(instance storagev1.CSIDriver) string {
+	return fmt.Sprintf("%s-creds", instance.GetDriverType())
+}
+
+// parseIsilonConfig - Parses the config key of the credentials secret of the Isilon driver
+func parseIsilonConfig(credsSecret *v1.Secret) (*isilonConfig, error) {
+	configBytes := credsSecret.Data["config"]
+	if len(configBytes) == 0 {
+		return nil, fmt.Errorf("secret %s: config key not found", credsSecret.Name)
+	}
+	config := &isilonConfig{}
+	err := yaml.Unmarshal(configBytes, config)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: unable to parse the config [%v]", credsSecret.Name, err)
+	}
+	return config, nil
+}
+
+// validateIsilonConfig - Validates the PowerScale clusters in the credentials secret of the Isilon driver
+// All the problems found are reported in the returned error, prefixed with the cluster they belong to
+func validateIsilonConfig(secretName string, config *isilonConfig) error {
+	reasons := make([]string, 0)
+	if len(config.IsilonClusters) == 0 {
+		reasons = append(reasons, "isilonClusters: no cluster configured")
+	}
+	defaults := 0
+	clusterNames := make(map[string]int)
+	for i, cluster := range config.IsilonClusters {
+		field := fmt.Sprintf("isilonClusters[%d]", i)
+		if cluster.ClusterName == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.clusterName: must be set", field))
+		} else if j, found := clusterNames[cluster.ClusterName]; found {
+			reasons = append(reasons, fmt.Sprintf("%s.clusterName: duplicate clusterName %s (also used by isilonClusters[%d])",
+				field, cluster.ClusterName, j))
+		} else {
+			clusterNames[cluster.ClusterName] = i
+		}
+		if cluster.Username == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.username: must be set", field))
+		}
+		if cluster.Password == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.password: must be set", field))
+		}
+		if cluster.Endpoint == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.endpoint: must be set", field))
+		}
+		if cluster.EndpointPort != nil && !isValidIsilonPort(*cluster.EndpointPort) {
+			reasons = append(reasons, fmt.Sprintf("%s.endpointPort: %s isn't in the range 1-65535",
+				field, cluster.EndpointPort.String()))
+		}
+		if cluster.IsiPath != "" && !strings.HasPrefix(cluster.IsiPath, "/") {
+			reasons = append(reasons, fmt.Sprintf("%s.isiPath: %s must be an absolute path", field, cluster.IsiPath))
+		}
+		if cluster.IsDefault {
+			defaults++
+		}
+	}
+	if len(config.IsilonClusters) != 0 && defaults != 1 {
+		reasons = append(reasons, fmt.Sprintf("isilonClusters: exactly one cluster must have isDefault: true, found %d",
+			defaults))
+	}
+	if len(reasons) != 0 {
+		return fmt.Errorf("invalid config in secret %s: %s", secretName, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// isValidIsilonPort - Returns true if the port of the OneFS API server is a number in the range 1-65535
+func isValidIsilonPort(port intstr.IntOrString) bool {
+	value := int(port.IntVal)
+	if port.Type == intstr.String {
+		var err error
+		value, err = strconv.Atoi(port.StrVal)
+		if err != nil {
+			return false
+		}
+	}
+	return value >= 1 && value <= 65535
+}
+
+// ValidateStorageArrays - Validates the credentials secret of the driver & returns the PowerScale clusters it
+// configures
+func (r *CSIIsilonReconciler) ValidateStorageArrays(ctx context.Context, instance storagev1.CSIDriver,
+	log logr.Logger) ([]storagev1.StorageArrayStatus, error) {
+	secretName := getIsilonCredsSecretName(instance)
+	credsSecret := &v1.Secret{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.GetNamespace()}, credsSecret)
+	if k8serror.IsNotFound(err) {
+		return nil, utils.NewMissingSecretError(secretName,
+			fmt.Errorf("failed to find the credentials secret: [%s]", secretName))
+	} else if err != nil {
+		log.Error(err, "Failed to query for the credentials secret. Warning - the driver pods may not start")
+		return instance.GetDriverStatus().StorageArrays, nil
+	}
+	config, err := parseIsilonConfig(credsSecret)
+	if err != nil {
+		return nil, err
+	}
+	err = validateIsilonConfig(secretName, config)
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]storagev1.StorageArrayStatus, 0, len(config.IsilonClusters))
+	for _, cluster := range config.IsilonClusters {
+		clusters = append(clusters, storagev1.StorageArrayStatus{
+			ID:        cluster.ClusterName,
+			Endpoint:  cluster.Endpoint,
+			IsDefault: cluster.IsDefault,
+		})
+	}
+	return clusters, nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -32,6 +33,7 @@ import (
 
 	storagev1 "github.com/dell/dell-csi-operator/api/v1"
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 )
 
 // CSIIsilonReconciler reconciles a CSIIsilon object
@@ -40,6 +42,7 @@ type CSIIsilonReconciler struct {
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      operatorconfig.Config
+	Recorder    record.EventRecorder
 	updateCount int32
 }
 
@@ -87,6 +90,11 @@ func (r *CSIIsilonReconciler) GetClient() client.Client {
 	return r.Client
 }
 
+// GetEventRecorder - Returns the event recorder
+func (r *CSIIsilonReconciler) GetEventRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
 // GetScheme - Returns k8s runtime scheme
 func (r *CSIIsilonReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
@@ -126,8 +134,9 @@ func (r *CSIIsilonReconciler) InitializeDriverSpec(instance storagev1.CSIDriver,
 
 // ValidateDriverSpec - Validates the driver spec
 // returns error if the spec is not valid
-func (r *CSIIsilonReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, reqLogger logr.Logger) error {
-	//Return nil, if the driver do not want to validate any params
+func (r *CSIIsilonReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
+	// The credentials secret is validated with the storage arrays (see ValidateStorageArrays)
 	return nil
 }
 
@@ -162,5 +171,11 @@ func (r *CSIIsilonReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIIsilon{}, &storagev1.CSIIsilonList{}, driverReferenceIndexValues,
+		r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
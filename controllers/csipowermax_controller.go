@@ -29,6 +29,7 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -37,6 +38,7 @@ import (
 
 	storagev1 "github.com/dell/dell-csi-operator/api/v1"
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 )
 
 // CSIPowerMaxReconciler reconciles a CSIPowerMax object
@@ -45,6 +47,7 @@ type CSIPowerMaxReconciler struct {
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      operatorconfig.Config
+	Recorder    record.EventRecorder
 	updateCount int32
 }
 
@@ -111,6 +114,12 @@ func (r *CSIPowerMaxReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIPowerMax{}, &storagev1.CSIPowerMaxList{}, driverReferenceIndexValues,
+		r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
 
@@ -124,6 +133,11 @@ func (r *CSIPowerMaxReconciler) GetClient() client.Client {
 	return r.Client
 }
 
+// GetEventRecorder - Returns the event recorder
+func (r *CSIPowerMaxReconciler) GetEventRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
 // GetScheme - Returns k8s runtime scheme
 func (r *CSIPowerMaxReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
@@ -198,10 +212,11 @@ func (r *CSIPowerMaxReconciler) InitializeDriverSpec(instance storagev1.CSIDrive
 
 // ValidateDriverSpec - Validates the driver spec
 // returns error if the spec is not valid
-func (r *CSIPowerMaxReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, reqLogger logr.Logger) error {
+func (r *CSIPowerMaxReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
 	//Return nil, if the driver do not want to validate any params
 	driver := instance.GetDriver()
-	versionStr := strings.ReplaceAll(driver.ConfigVersion, "v", "")
+	versionStr := strings.ReplaceAll(utils.GetConfigVersion(instance), "v", "")
 	versionStr = strings.ReplaceAll(versionStr, ".", "")
 	version, err := strconv.Atoi(versionStr)
 	if err != nil {
@@ -216,5 +231,5 @@ func (r *CSIPowerMaxReconciler) ValidateDriverSpec(ctx context.Context, instance
 			}
 		}
 	}
-	return nil
+	return validateVSphere(ctx, r.GetClient(), instance, driverConfig, reqLogger)
 }
@@ -0,0 +1,135 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Environment variables of the vSphere (RDM) support of the PowerMax driver
+const (
+	vSphereEnabledEnv   = "X_CSI_VSPHERE_ENABLED"
+	vSpherePortGroupEnv = "X_CSI_VSPHERE_PORTGROUP"
+	vSphereHostNameEnv  = "X_CSI_VSPHERE_HOSTNAME"
+	vCenterHostEnv      = "X_CSI_VCENTER_HOST"
+	vCenterUserNameEnv  = "X_CSI_VCENTER_USERNAME"
+	vCenterPasswordEnv  = "X_CSI_VCENTER_PWD"
+)
+
+// vCenterCredentialEnvs - Envs of the driver referencing the vCenter credentials secret
+var vCenterCredentialEnvs = []string{vCenterUserNameEnv, vCenterPasswordEnv}
+
+// findPowerMaxEnv - Returns the env of the controller or node plugin & its field path in the driver spec
+// The envs of the plugin take priority over the common envs. Returns nil if the env isn't set in the spec
+func findPowerMaxEnv(driver *storagev1.Driver, plugin, name string) (*v1.EnvVar, string) {
+	pluginEnvs := driver.Controller.Envs
+	if plugin == "node" {
+		pluginEnvs = driver.Node.Envs
+	}
+	for i := range pluginEnvs {
+		if pluginEnvs[i].Name == name {
+			return &pluginEnvs[i], fmt.Sprintf("spec.driver.%s.envs[%d]", plugin, i)
+		}
+	}
+	for i := range driver.Common.Envs {
+		if driver.Common.Envs[i].Name == name {
+			return &driver.Common.Envs[i], fmt.Sprintf("spec.driver.common.envs[%d]", i)
+		}
+	}
+	return nil, fmt.Sprintf("spec.driver.%s.envs", plugin)
+}
+
+// getDefaultSecretKeyRef - Returns the secret key referenced by default by the env of the controller or node plugin
+// in the driver config. Returns nil if the default value of the env isn't a secret reference
+func getDefaultSecretKeyRef(driverConfig *ctrlconfig.Config, plugin, name string) *v1.SecretKeySelector {
+	envs := driverConfig.GetControllerEnvs()
+	if plugin == "node" {
+		envs = driverConfig.GetNodeEnvs()
+	}
+	for _, env := range envs {
+		if env.Name == name && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			return env.ValueFrom.SecretKeyRef
+		}
+	}
+	return nil
+}
+
+// validateVSphere - Validates the vSphere settings of the controller & node plugins of the PowerMax driver
+// The host group, port group & vCenter host must be set & the vCenter credentials secret must hold the
+// username & password when vSphere is enabled. The credentials are read from the secret referenced by default
+// in the driver config unless the envs of the driver reference another one
+func validateVSphere(ctx context.Context, c client.Client, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
+	driver := instance.GetDriver()
+	for _, plugin := range []string{"controller", "node"} {
+		enabled, _ := findPowerMaxEnv(driver, plugin, vSphereEnabledEnv)
+		if enabled == nil || !strings.EqualFold(strings.TrimSpace(enabled.Value), "true") {
+			continue
+		}
+		for _, name := range []string{vSphereHostNameEnv, vSpherePortGroupEnv, vCenterHostEnv} {
+			env, path := findPowerMaxEnv(driver, plugin, name)
+			if env == nil || strings.TrimSpace(env.Value) == "" {
+				return fmt.Errorf("%s: %s must be set when %s is true", path, name, vSphereEnabledEnv)
+			}
+		}
+		for _, name := range vCenterCredentialEnvs {
+			secretKeyRef := getDefaultSecretKeyRef(driverConfig, plugin, name)
+			env, path := findPowerMaxEnv(driver, plugin, name)
+			if env != nil {
+				secretKeyRef = nil
+				if env.ValueFrom != nil {
+					secretKeyRef = env.ValueFrom.SecretKeyRef
+				}
+			}
+			if secretKeyRef == nil {
+				return fmt.Errorf("%s: %s must reference the vCenter credentials secret", path, name)
+			}
+			err := checkVCenterSecretKey(ctx, c, secretKeyRef.Name, secretKeyRef.Key, instance.GetNamespace(), reqLogger)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkVCenterSecretKey - Checks that the vCenter credentials secret exists & holds a non empty value for the key
+func checkVCenterSecretKey(ctx context.Context, c client.Client, secretName, key, namespace string,
+	reqLogger logr.Logger) error {
+	secret := &v1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret)
+	if errors.IsNotFound(err) {
+		return utils.NewMissingSecretError(secretName,
+			fmt.Errorf("failed to find the vCenter credentials secret: [%s]", secretName))
+	} else if err != nil {
+		reqLogger.Error(err, "Failed to query for the vCenter credentials secret. Warning - the driver pods may not start")
+		return nil
+	}
+	if len(secret.Data[key]) == 0 {
+		return fmt.Errorf("data.%s: key not found in the vCenter credentials secret %s", key, secretName)
+	}
+	return nil
+}
@@ -24,7 +24,9 @@ import (
 	"time"
 
 	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
 	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources"
 	"github.com/dell/dell-csi-operator/pkg/resources/configmap"
 	"github.com/dell/dell-csi-operator/pkg/resources/deployment"
 	"github.com/dell/dell-csi-operator/pkg/resources/rbac"
@@ -42,6 +44,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -64,6 +67,12 @@ const (
 	TLSSecretMountPath       = "/app/tls"
 	CertVolumeName           = "cert-dir"
 	CertVolumeMountPath      = "/app/certs"
+	TrustBundleVolumeName    = "trust-bundle"
+	TrustBundleMountPath     = "/app/trust"
+	TrustBundleFileName      = "ca-bundle.crt"
+	DefaultTLSSecretName     = "powermax-reverseproxy-tls"
+	CASecretName             = "powermax-reverseproxy-ca"
+	CACertSecretName         = "powermax-reverseproxy-ca-cert"
 )
 
 var log = klogr.New().WithName("CSIPowerMaxReverseProxy")
@@ -71,11 +80,15 @@ var log = klogr.New().WithName("CSIPowerMaxReverseProxy")
 // CSIPowerMaxRevProxyReconciler reconciles a CSIPowerMaxRevProxy object
 type CSIPowerMaxRevProxyReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Config   operatorconfig.Config
 }
 
 // +kubebuilder:rbac:groups=storage.dell.com,resources=csipowermaxrevproxies;csipowermaxrevproxies/finalizers;csipowermaxrevproxies/status,verbs=*
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions,verbs=get
 
 // Reconcile function reconciles a CSIPowerMax object
 func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
@@ -144,9 +157,21 @@ func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request c
 	case constants.Updating:
 		reqLogger.Info("Proxy already in Updating state")
 	}
+	if checkStateOnly && isProxySyncDue(context.TODO(), instance, r.Client) {
+		// The TLS certificate has to be renewed, or the Secrets or the trust bundle of the proxy changed
+		// since the proxy pod was rolled out
+		reqLogger.Info("Changed state to Updating as the proxy has to be synced")
+		newStatus.State = constants.Updating
+		checkStateOnly = false
+	}
 	// Check if proxy is in running state (only if the status was previously set to Succeeded or Running)
 	if checkStateOnly {
-		return handleSuccess(context.TODO(), instance, r.Client, reqLogger, newStatus, oldStatus)
+		err = r.mirrorSecrets(context.TODO(), instance, reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "Failed to mirror the secrets referenced from other namespaces")
+		}
+		certCheckInterval := r.checkCertificateExpiry(context.TODO(), instance, newStatus, reqLogger)
+		return handleSuccess(context.TODO(), instance, r.Client, reqLogger, newStatus, oldStatus, certCheckInterval)
 	}
 	if changed {
 		// Also update the status as we calculate the hash every time
@@ -159,6 +184,25 @@ func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request c
 			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, updateStatusError, reqLogger)
 		}
 	}
+	// Create the ConfigMap in which OpenShift injects its trusted CA bundle before the spec is validated
+	err = utils.SyncTrustedCABundleConfigMap(context.TODO(), instance.Spec.TrustBundle,
+		utils.GetMirrorOwnerReference(getOwnerReferences(instance)), instance.Namespace, r.Client, reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failed to create the ConfigMap for the trusted CA bundle")
+		return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, err, reqLogger)
+	}
+	// Copy the secrets referenced from other namespaces before the spec is validated
+	err = utils.ValidateSecretRefs(getProxySecretRefs(instance), instance.Namespace, r.Config.SecretSourceNamespaces)
+	if err == nil {
+		err = r.mirrorSecrets(context.TODO(), instance, reqLogger)
+		if err != nil && !utils.IsMissingDependency(err) {
+			reqLogger.Error(err, "Failed to mirror the secrets referenced from other namespaces")
+			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, err, reqLogger)
+		}
+	}
+	if err != nil {
+		return handleValidationError(context.TODO(), instance, r.Client, reqLogger, err)
+	}
 	// Always validate the spec
 	err = ValidateProxySpec(context.TODO(), r.Client, instance)
 	if err != nil {
@@ -180,6 +224,7 @@ func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request c
 		}
 		newStatus.LastUpdate = setLastStatusUpdate(oldStatus,
 			utils.GetOperatorConditionTypeFromState(newStatus.State), errorMsg)
+		certCheckInterval := r.checkCertificateExpiry(context.TODO(), instance, newStatus, reqLogger)
 		updateStatusError := updateStatus(context.TODO(), instance, r.Client, reqLogger, newStatus, oldStatus)
 		if updateStatusError != nil {
 			return reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, updateStatusError
@@ -187,6 +232,10 @@ func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request c
 		if newStatus.State != constants.Running {
 			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, nil, reqLogger)
 		}
+		if certCheckInterval != 0 {
+			// Check the expiry of the certificates again
+			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: certCheckInterval}, nil, reqLogger)
+		}
 		return logBannerAndReturn(reconcile.Result{}, nil, reqLogger)
 	}
 	// Failed to sync proxy deployment
@@ -198,7 +247,7 @@ func (r *CSIPowerMaxRevProxyReconciler) Reconcile(ctx context.Context, request c
 		reqLogger.Info(" Proxy previously encountered an error")
 		timeSinceLastConditionChange := metav1.Now().Sub(oldStatus.LastUpdate.Time.Time).Round(time.Second)
 		reqLogger.Info(fmt.Sprintf("Time since last condition change :%v", timeSinceLastConditionChange))
-		if timeSinceLastConditionChange >= constants.MaxRetryDuration {
+		if timeSinceLastConditionChange >= constants.DefaultMaxRetryDuration {
 			// Mark the proxy as failed and update the condition
 			newStatus.State = constants.Failed
 			newStatus.LastUpdate = setLastStatusUpdate(oldStatus,
@@ -250,6 +299,13 @@ func (r *CSIPowerMaxRevProxyReconciler) SetupWithManager(mgr ctrl.Manager) error
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	// Roll out the proxy pod when the TLS certificate is renewed by cert-manager
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIPowerMaxRevProxy{}, &storagev1.CSIPowerMaxRevProxyList{},
+		proxyReferenceIndexValues, r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
 
@@ -260,12 +316,83 @@ func setStatus(instance *storagev1.CSIPowerMaxRevProxy, newStatus *storagev1.CSI
 	instance.Status.LastUpdate.Time = newStatus.LastUpdate.Time
 	instance.Status.ProxyStatus = newStatus.ProxyStatus
 	instance.Status.ProxyHash = newStatus.ProxyHash
+	instance.Status.CertificateExpiry = newStatus.CertificateExpiry
+	instance.Status.Conditions = newStatus.Conditions
+}
+
+// checkCertificateExpiry - Checks the expiry of the TLS certificate of the proxy & of the certificates of the
+// management servers. Returns the time after which the expiry has to be checked again (0 if there are no certificates)
+func (r *CSIPowerMaxRevProxyReconciler) checkCertificateExpiry(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
+	newStatus *storagev1.CSIPowerMaxRevProxyStatus, reqLogger logr.Logger) time.Duration {
+	secretNames := []string{getTLSSecretName(instance)}
+	managementServers := make([]storagev1.ManagementServerConfig, 0)
+	if linkConfig := instance.Spec.RevProxy.LinkConfig; linkConfig != nil {
+		managementServers = append(managementServers, linkConfig.Primary, linkConfig.Backup)
+	}
+	if standAloneConfig := instance.Spec.RevProxy.StandAloneConfig; standAloneConfig != nil {
+		managementServers = append(managementServers, standAloneConfig.ManagementServerConfig...)
+	}
+	for _, managementServer := range managementServers {
+		if managementServer.CertSecret != "" {
+			secretNames = append(secretNames, utils.LocalSecretName(managementServer.CertSecret))
+		}
+	}
+	expiry, nextCheck := utils.CheckCertificateExpiry(ctx, r.Client, r.Recorder, instance, ReverseProxyName, secretNames,
+		instance.Spec.CertExpiryThresholds, instance.Status.CertificateExpiry, &newStatus.Conditions, reqLogger)
+	newStatus.CertificateExpiry = expiry
+	return nextCheck
+}
+
+// mirrorSecrets - Mirrors the secrets referenced from other namespaces in the proxy spec
+func (r *CSIPowerMaxRevProxyReconciler) mirrorSecrets(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
+	reqLogger logr.Logger) error {
+	owner := utils.GetMirrorOwnerReference(getOwnerReferences(instance))
+	return utils.MirrorSecrets(ctx, owner, instance.Namespace, getProxySecretRefs(instance),
+		r.Config.SecretSourceNamespaces, r.Client, reqLogger)
+}
+
+// getProxySecretRefs - Returns the secret references in the proxy spec
+func getProxySecretRefs(cr *storagev1.CSIPowerMaxRevProxy) []string {
+	refs := make([]string, 0)
+	if cr.Spec.TLSSecret != "" && getTLSMode(cr) == storagev1.ProxyTLSModeProvided {
+		refs = append(refs, cr.Spec.TLSSecret)
+	}
+	if bundle := cr.Spec.TrustBundle; bundle != nil && bundle.SecretKeyRef != nil {
+		refs = append(refs, bundle.SecretKeyRef.Name)
+	}
+	managementServers := make([]storagev1.ManagementServerConfig, 0)
+	if linkConfig := cr.Spec.RevProxy.LinkConfig; linkConfig != nil {
+		managementServers = append(managementServers, linkConfig.Primary, linkConfig.Backup)
+	}
+	if standAloneConfig := cr.Spec.RevProxy.StandAloneConfig; standAloneConfig != nil {
+		managementServers = append(managementServers, standAloneConfig.ManagementServerConfig...)
+		for _, storageArray := range standAloneConfig.StorageArrayConfig {
+			refs = append(refs, storageArray.ProxyCredentialSecrets...)
+		}
+	}
+	for _, managementServer := range managementServers {
+		if managementServer.CertSecret != "" {
+			refs = append(refs, managementServer.CertSecret)
+		}
+		if managementServer.ArrayCredentialSecret != "" {
+			refs = append(refs, managementServer.ArrayCredentialSecret)
+		}
+	}
+	return refs
 }
 
 // ValidateProxySpec - Validates the proxy specification
 func ValidateProxySpec(ctx context.Context, client client.Client, instance *storagev1.CSIPowerMaxRevProxy) error {
 	proxySpec := instance.Spec
-	err := checkIfSecretExists(ctx, client, proxySpec.TLSSecret, instance.Namespace)
+	err := validateProxyTLS(ctx, client, instance)
+	if err != nil {
+		return err
+	}
+	err = utils.ValidateCertExpiryThresholds(proxySpec.CertExpiryThresholds)
+	if err != nil {
+		return err
+	}
+	err = validateProxyTrustBundle(ctx, client, instance)
 	if err != nil {
 		return err
 	}
@@ -293,12 +420,14 @@ func validateLinkedProxySpec(ctx context.Context, client client.Client, instance
 		return fmt.Errorf("linkConfig primary URL is not of the proper format. Error: %s", err.Error())
 	}
 	if linkConfig.Primary.SkipCertificateValidation == false {
-		if linkConfig.Primary.CertSecret == "" {
+		if linkConfig.Primary.CertSecret == "" && instance.Spec.TrustBundle == nil {
 			return fmt.Errorf("link config Primary: SkipCertificateValidation is set to false and cert secret has not been specified")
 		}
-		err = checkIfSecretExists(ctx, client, linkConfig.Primary.CertSecret, instance.Namespace)
-		if err != nil {
-			return err
+		if linkConfig.Primary.CertSecret != "" {
+			err = checkIfSecretExists(ctx, client, linkConfig.Primary.CertSecret, instance.Namespace)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	// Backup
@@ -308,12 +437,14 @@ func validateLinkedProxySpec(ctx context.Context, client client.Client, instance
 			return fmt.Errorf("linkConfig backup URL is not of the proper format. Error: %s", err.Error())
 		}
 		if linkConfig.Backup.SkipCertificateValidation == false {
-			if linkConfig.Backup.CertSecret == "" {
+			if linkConfig.Backup.CertSecret == "" && instance.Spec.TrustBundle == nil {
 				return fmt.Errorf("link config Backup: SkipCertificateValidation is set to false and cert secret has not been specified")
 			}
-			err = checkIfSecretExists(ctx, client, linkConfig.Backup.CertSecret, instance.Namespace)
-			if err != nil {
-				return err
+			if linkConfig.Backup.CertSecret != "" {
+				err = checkIfSecretExists(ctx, client, linkConfig.Backup.CertSecret, instance.Namespace)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -343,12 +474,14 @@ func validateStandAloneProxySpec(ctx context.Context, client client.Client, inst
 
 		// Check cert secret
 		if managementServer.SkipCertificateValidation == false {
-			if managementServer.CertSecret == "" {
+			if managementServer.CertSecret == "" && instance.Spec.TrustBundle == nil {
 				return fmt.Errorf("one of the management server's SkipCertificateValidation is set to false and cert secret has not been specified")
 			}
-			err = checkIfSecretExists(ctx, client, managementServer.CertSecret, instance.Namespace)
-			if err != nil {
-				return err
+			if managementServer.CertSecret != "" {
+				err = checkIfSecretExists(ctx, client, managementServer.CertSecret, instance.Namespace)
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -397,6 +530,26 @@ func validateStandAloneProxySpec(ctx context.Context, client client.Client, inst
 	return nil
 }
 
+// validateProxyTrustBundle - Validates the trust bundle in the proxy spec
+func validateProxyTrustBundle(ctx context.Context, client client.Client, instance *storagev1.CSIPowerMaxRevProxy) error {
+	bundle := instance.Spec.TrustBundle
+	if bundle == nil {
+		return nil
+	}
+	err := utils.ValidateTrustBundle(bundle)
+	if err != nil {
+		return err
+	}
+	_, err = utils.GetTrustBundle(ctx, bundle, instance.Namespace, client)
+	if err != nil {
+		if utils.IsMissingDependency(err) {
+			return err
+		}
+		log.Error(err, "Failed to query for the trust bundle. Warning - the proxy pod may not start")
+	}
+	return nil
+}
+
 func boolPtr(i bool) *bool { return &i }
 
 func handleValidationError(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger,
@@ -453,7 +606,8 @@ func updateStatus(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
 	return nil
 }
 
-func handleSuccess(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger, newStatus, oldStatus *storagev1.CSIPowerMaxRevProxyStatus) (reconcile.Result, error) {
+func handleSuccess(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger, newStatus, oldStatus *storagev1.CSIPowerMaxRevProxyStatus,
+	certCheckInterval time.Duration) (reconcile.Result, error) {
 	errorMsg := ""
 	running, err := utils.CalculateProxyState(ctx, ReverseProxyName, instance.Namespace, client, newStatus)
 	if err != nil {
@@ -483,7 +637,7 @@ func handleSuccess(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
 		} else if oldStatus.State == constants.Succeeded {
 			timeSinceLastConditionChange := metav1.Now().Sub(oldStatus.LastUpdate.Time.Time).Round(time.Millisecond)
 			reqLogger.Info(fmt.Sprintf("Time since last condition change: %v", timeSinceLastConditionChange))
-			if timeSinceLastConditionChange >= constants.MaxRetryDuration {
+			if timeSinceLastConditionChange >= constants.DefaultMaxRetryDuration {
 				// Don't requeue again
 				requeue = false
 				reqLogger.Info("Time elapsed since last condition change is more than max limit. Not going to reconcile")
@@ -498,6 +652,11 @@ func handleSuccess(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
 	} else {
 		requeue = true
 	}
+	if !requeue && certCheckInterval != 0 {
+		// Check the expiry of the certificates again
+		requeue = true
+		retryInterval = certCheckInterval
+	}
 	updateStatusError := updateStatus(ctx, instance, client, reqLogger, newStatus, oldStatus)
 	if updateStatusError != nil {
 		reqLogger.Error(updateStatusError, "failed to update the status")
@@ -513,7 +672,7 @@ func handleSuccess(ctx context.Context, instance *storagev1.CSIPowerMaxRevProxy,
 
 func checkIfSecretExists(ctx context.Context, client client.Client, secretName, namespace string) error {
 	found := &v1.Secret{}
-	err := client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, found)
+	err := client.Get(ctx, types.NamespacedName{Name: utils.LocalSecretName(secretName), Namespace: namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		return fmt.Errorf("failed to find secret: [%s]", secretName)
 	} else if err != nil {
@@ -593,8 +752,8 @@ func newDeploymentForCR(cr *storagev1.CSIPowerMaxRevProxy) *appsv1.Deployment {
 						{
 							Name:            "csireverseproxy",
 							Image:           cr.Spec.Image,
-							Env:             proxyEnvs(cr.Namespace),
-							VolumeMounts:    volumeMounts(),
+							Env:             proxyEnvs(cr),
+							VolumeMounts:    volumeMounts(cr),
 							ImagePullPolicy: imagePullPolicy,
 						},
 					},
@@ -656,7 +815,7 @@ func newServiceAccount(cr *storagev1.CSIPowerMaxRevProxy) *v1.ServiceAccount {
 }
 
 // volumeMounts - Returns a slice of volume mounts for the reverseproxy container
-func volumeMounts() []v1.VolumeMount {
+func volumeMounts(cr *storagev1.CSIPowerMaxRevProxy) []v1.VolumeMount {
 	volumeMounts := make([]v1.VolumeMount, 0)
 	configMapMount := v1.VolumeMount{
 		Name:      ConfigMapVolumeName,
@@ -673,6 +832,13 @@ func volumeMounts() []v1.VolumeMount {
 	volumeMounts = append(volumeMounts, configMapMount)
 	volumeMounts = append(volumeMounts, secretMount)
 	volumeMounts = append(volumeMounts, emptyDirMount)
+	if cr.Spec.TrustBundle != nil {
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      TrustBundleVolumeName,
+			MountPath: TrustBundleMountPath,
+			ReadOnly:  true,
+		})
+	}
 	return volumeMounts
 }
 
@@ -694,7 +860,7 @@ func volumes(cr *storagev1.CSIPowerMaxRevProxy) []v1.Volume {
 		Name: TLSSecretVolumeName,
 		VolumeSource: v1.VolumeSource{
 			Secret: &v1.SecretVolumeSource{
-				SecretName: cr.Spec.TLSSecret,
+				SecretName: getTLSSecretName(cr),
 				Optional:   boolPtr(false),
 			},
 		},
@@ -710,13 +876,45 @@ func volumes(cr *storagev1.CSIPowerMaxRevProxy) []v1.Volume {
 	volumes = append(volumes, configMapVol)
 	volumes = append(volumes, secretVol)
 	volumes = append(volumes, emptyDirVol)
+	if bundle := cr.Spec.TrustBundle; bundle != nil {
+		trustBundleVol := v1.Volume{Name: TrustBundleVolumeName}
+		if ref := bundle.ConfigMapKeyRef; ref != nil {
+			trustBundleVol.VolumeSource.ConfigMap = &v1.ConfigMapVolumeSource{
+				LocalObjectReference: ref.LocalObjectReference,
+				Items:                []v1.KeyToPath{{Key: ref.Key, Path: TrustBundleFileName}},
+				Optional:             ref.Optional,
+			}
+		} else if ref := bundle.SecretKeyRef; ref != nil {
+			trustBundleVol.VolumeSource.Secret = &v1.SecretVolumeSource{
+				SecretName: utils.LocalSecretName(ref.Name),
+				Items:      []v1.KeyToPath{{Key: ref.Key, Path: TrustBundleFileName}},
+				Optional:   ref.Optional,
+			}
+		}
+		volumes = append(volumes, trustBundleVol)
+	}
 	return volumes
 }
 
 // Marshals the proxy configuration from the Custom Resource
 // and returns a configmap object
 func newConfigMapForCR(cr *storagev1.CSIPowerMaxRevProxy) (*v1.ConfigMap, error) {
-	config := cr.Spec.RevProxy
+	config := *cr.Spec.RevProxy.DeepCopy()
+	// The proxy reads the secrets referenced from other namespaces from their copies
+	if linkConfig := config.LinkConfig; linkConfig != nil {
+		localizeSecretRefs(&linkConfig.Primary)
+		localizeSecretRefs(&linkConfig.Backup)
+	}
+	if standAloneConfig := config.StandAloneConfig; standAloneConfig != nil {
+		for i := range standAloneConfig.ManagementServerConfig {
+			localizeSecretRefs(&standAloneConfig.ManagementServerConfig[i])
+		}
+		for _, storageArray := range standAloneConfig.StorageArrayConfig {
+			for i, credSecret := range storageArray.ProxyCredentialSecrets {
+				storageArray.ProxyCredentialSecrets[i] = utils.LocalSecretName(credSecret)
+			}
+		}
+	}
 	if config.Mode == "" {
 		config.Mode = DefaultMode
 	}
@@ -743,13 +941,24 @@ func newConfigMapForCR(cr *storagev1.CSIPowerMaxRevProxy) (*v1.ConfigMap, error)
 	}, nil
 }
 
-func proxyEnvs(namespace string) []v1.EnvVar {
+// localizeSecretRefs - Replaces the secret references of the management server by the names of the local secrets
+func localizeSecretRefs(managementServer *storagev1.ManagementServerConfig) {
+	managementServer.CertSecret = utils.LocalSecretName(managementServer.CertSecret)
+	managementServer.ArrayCredentialSecret = utils.LocalSecretName(managementServer.ArrayCredentialSecret)
+}
+
+func proxyEnvs(cr *storagev1.CSIPowerMaxRevProxy) []v1.EnvVar {
 	envVars := make([]v1.EnvVar, 0)
 	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVPROXY_CONFIG_DIR", Value: ConfigMapVolumeMountPath})
 	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVPROXY_CONFIG_FILE_NAME", Value: ConfigFileName})
 	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVRPOXY_IN_CLUSTER", Value: "true"})
 	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVPROXY_TLS_CERT_DIR", Value: TLSSecretMountPath})
-	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVPROXY_WATCH_NAMESPACE", Value: namespace})
+	envVars = append(envVars, v1.EnvVar{Name: "X_CSI_REVPROXY_WATCH_NAMESPACE", Value: cr.Namespace})
+	if cr.Spec.TrustBundle != nil {
+		// Used by the proxy in place of the system CA certificates
+		envVars = append(envVars, v1.EnvVar{Name: "SSL_CERT_FILE",
+			Value: fmt.Sprintf("%s/%s", TrustBundleMountPath, TrustBundleFileName)})
+	}
 	return envVars
 }
 
@@ -768,6 +977,11 @@ func getOwnerReferences(cr *storagev1.CSIPowerMaxRevProxy) []metav1.OwnerReferen
 
 // SyncProxy - syncs the proxy instance
 func SyncProxy(cr *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger) error {
+	// Provision the TLS certificate
+	err := syncProxyTLS(context.TODO(), cr, client, reqLogger)
+	if err != nil {
+		return err
+	}
 	// Create the configmap
 	configMap, err := newConfigMapForCR(cr)
 	if err != nil {
@@ -799,6 +1013,15 @@ func SyncProxy(cr *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogge
 		return err
 	}
 	proxyDeployment := newDeploymentForCR(cr)
+	// Roll out the proxy pod whenever the TLS certificate is renewed or the trust bundle changes
+	err = resources.SetPodTemplateSecretsChecksum(context.TODO(), &proxyDeployment.Spec.Template, cr.Namespace, client)
+	if err != nil {
+		return err
+	}
+	err = setTrustBundleChecksum(context.TODO(), cr, &proxyDeployment.Spec.Template, client)
+	if err != nil {
+		return err
+	}
 	err = deployment.SyncDeployment(context.TODO(), proxyDeployment, client, reqLogger)
 	if err != nil {
 		return err
@@ -806,6 +1029,40 @@ func SyncProxy(cr *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogge
 	return nil
 }
 
+// setTrustBundleChecksum - Annotates the pod template with a checksum of the trust bundle of the proxy (if any)
+func setTrustBundleChecksum(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, template *v1.PodTemplateSpec,
+	client client.Client) error {
+	checksum, err := utils.GetTrustBundleChecksum(ctx, cr.Spec.TrustBundle, cr.Namespace, client)
+	if err != nil || checksum == "" {
+		return err
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[constants.PodTemplateTrustBundleChecksumKey] = checksum
+	return nil
+}
+
+// isProxySyncDue - Returns true if the TLS certificate of the proxy has to be (re)issued by the operator, or if the
+// Secrets referenced by the proxy pod or the trust bundle changed since the proxy pod was last rolled out
+func isProxySyncDue(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client) bool {
+	if isProxyTLSSyncDue(ctx, cr, client) {
+		return true
+	}
+	deployment := &appsv1.Deployment{}
+	err := client.Get(ctx, types.NamespacedName{Name: ReverseProxyName, Namespace: cr.Namespace}, deployment)
+	if err != nil {
+		return false
+	}
+	annotations := deployment.Spec.Template.Annotations
+	checksum, err := resources.GetSecretsChecksum(ctx, deployment.Spec.Template.Spec, cr.Namespace, client)
+	if err == nil && checksum != annotations[constants.PodTemplateSecretsChecksumKey] {
+		return true
+	}
+	checksum, err = utils.GetTrustBundleChecksum(ctx, cr.Spec.TrustBundle, cr.Namespace, client)
+	return err == nil && checksum != annotations[constants.PodTemplateTrustBundleChecksumKey]
+}
+
 // SetClient sets the client for CSIPowerMaxRevProxyReconciler
 func (r *CSIPowerMaxRevProxyReconciler) SetClient(client client.Client) *CSIPowerMaxRevProxyReconciler {
 	r.Client = client
@@ -0,0 +1,528 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultTLSCertDuration - Default validity of the serving certificate of the proxy
+	defaultTLSCertDuration = 365 * 24 * time.Hour
+	// defaultTLSCertRenewBefore - Default time before the expiry of the serving certificate at which it is renewed
+	defaultTLSCertRenewBefore = 30 * 24 * time.Hour
+	// selfSignedCADuration - Validity of the CA generated in the SelfSigned TLS mode
+	selfSignedCADuration = 10 * 365 * 24 * time.Hour
+	// caCertKey - Key of the CA certificate in the CA, CA certificate & TLS secrets
+	caCertKey = "ca.crt"
+	// caPrivateKeyKey - Key of the private key of the CA in the CA secret
+	caPrivateKeyKey = "ca.key"
+	// certManagerCRDName - Name of the CRD of the cert-manager Certificates
+	certManagerCRDName = "certificates.cert-manager.io"
+	// certManagerGroup - API group of cert-manager
+	certManagerGroup = "cert-manager.io"
+)
+
+// certificateGVK - GroupVersionKind of the cert-manager Certificates
+var certificateGVK = schema.GroupVersionKind{Group: certManagerGroup, Version: "v1", Kind: "Certificate"}
+
+// crdGVK - GroupVersionKind of the CustomResourceDefinitions
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// getTLSMode - Returns the mode in which the TLS certificate of the proxy is provisioned
+func getTLSMode(cr *storagev1.CSIPowerMaxRevProxy) storagev1.ProxyTLSMode {
+	if cr.Spec.TLS == nil || cr.Spec.TLS.Mode == "" {
+		return storagev1.ProxyTLSModeProvided
+	}
+	return cr.Spec.TLS.Mode
+}
+
+// getTLSSecretName - Returns the name of the secret holding the TLS certificate of the proxy
+func getTLSSecretName(cr *storagev1.CSIPowerMaxRevProxy) string {
+	if cr.Spec.TLSSecret == "" && getTLSMode(cr) != storagev1.ProxyTLSModeProvided {
+		return DefaultTLSSecretName
+	}
+	return utils.LocalSecretName(cr.Spec.TLSSecret)
+}
+
+// getTLSDurations - Returns the validity of the serving certificate & the time before its expiry at which it is renewed
+func getTLSDurations(cr *storagev1.CSIPowerMaxRevProxy) (time.Duration, time.Duration) {
+	duration, renewBefore := defaultTLSCertDuration, defaultTLSCertRenewBefore
+	if tls := cr.Spec.TLS; tls != nil {
+		if tls.Duration != nil {
+			duration = tls.Duration.Duration
+		}
+		if tls.RenewBefore != nil {
+			renewBefore = tls.RenewBefore.Duration
+		}
+	}
+	return duration, renewBefore
+}
+
+// getProxyDNSNames - Returns the DNS names of the proxy service
+func getProxyDNSNames(cr *storagev1.CSIPowerMaxRevProxy) []string {
+	return []string{
+		ReverseProxyName,
+		fmt.Sprintf("%s.%s", ReverseProxyName, cr.Namespace),
+		fmt.Sprintf("%s.%s.svc", ReverseProxyName, cr.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", ReverseProxyName, cr.Namespace),
+	}
+}
+
+// validateProxyTLS - Validates the TLS configuration of the proxy
+func validateProxyTLS(ctx context.Context, client client.Client, cr *storagev1.CSIPowerMaxRevProxy) error {
+	mode := getTLSMode(cr)
+	if mode != storagev1.ProxyTLSModeProvided && strings.Contains(cr.Spec.TLSSecret, "/") {
+		// The operator only manages the TLS secret in the namespace of the proxy
+		return fmt.Errorf("tlsSecret %s can't be referenced from another namespace in the %s TLS mode", cr.Spec.TLSSecret, mode)
+	}
+	switch mode {
+	case storagev1.ProxyTLSModeProvided:
+		if cr.Spec.TLSSecret == "" {
+			return fmt.Errorf("tlsSecret must be specified in the %s TLS mode", mode)
+		}
+		return checkIfSecretExists(ctx, client, cr.Spec.TLSSecret, cr.Namespace)
+	case storagev1.ProxyTLSModeSelfSigned:
+		return validateTLSDurations(cr)
+	case storagev1.ProxyTLSModeCertManager:
+		err := validateTLSDurations(cr)
+		if err != nil {
+			return err
+		}
+		issuerRef := cr.Spec.TLS.IssuerRef
+		if issuerRef == nil || issuerRef.Name == "" {
+			return fmt.Errorf("tls.issuerRef must be specified in the %s TLS mode", mode)
+		}
+		if (issuerRef.Group == "" || issuerRef.Group == certManagerGroup) &&
+			issuerRef.Kind != "" && issuerRef.Kind != "Issuer" && issuerRef.Kind != "ClusterIssuer" {
+			return fmt.Errorf("invalid tls.issuerRef kind %s: must be Issuer or ClusterIssuer", issuerRef.Kind)
+		}
+		installed, err := isCertManagerInstalled(ctx, client)
+		if err != nil {
+			log.Error(err, "Failed to query for the cert-manager CRDs. Warning - the proxy pod may not start")
+			return nil
+		}
+		if !installed {
+			return fmt.Errorf("cert-manager isn't installed: failed to find CRD %s", certManagerCRDName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown TLS mode %s specified", mode)
+	}
+}
+
+// validateTLSDurations - Validates the validity & the renewal time of the serving certificate
+func validateTLSDurations(cr *storagev1.CSIPowerMaxRevProxy) error {
+	if tls := cr.Spec.TLS; tls != nil {
+		if tls.Duration != nil && tls.Duration.Duration <= 0 {
+			return fmt.Errorf("invalid tls.duration %s: must be greater than 0", tls.Duration.Duration)
+		}
+		if tls.RenewBefore != nil && tls.RenewBefore.Duration <= 0 {
+			return fmt.Errorf("invalid tls.renewBefore %s: must be greater than 0", tls.RenewBefore.Duration)
+		}
+	}
+	duration, renewBefore := getTLSDurations(cr)
+	if renewBefore >= duration {
+		return fmt.Errorf("invalid tls.renewBefore %s: must be less than the duration %s", renewBefore, duration)
+	}
+	return nil
+}
+
+// isCertManagerInstalled - Returns true if the CRD of the cert-manager Certificates is present
+func isCertManagerInstalled(ctx context.Context, client client.Client) (bool, error) {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	err := client.Get(ctx, types.NamespacedName{Name: certManagerCRDName}, crd)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// syncProxyTLS - Provisions the TLS certificate of the proxy as per the TLS mode
+func syncProxyTLS(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger) error {
+	switch getTLSMode(cr) {
+	case storagev1.ProxyTLSModeSelfSigned:
+		return syncSelfSignedTLS(ctx, cr, client, reqLogger)
+	case storagev1.ProxyTLSModeCertManager:
+		return syncCertManagerCertificate(ctx, cr, client, reqLogger)
+	}
+	return nil
+}
+
+// isProxyTLSSyncDue - Returns true if the TLS certificate of the proxy has to be (re)issued by the operator
+func isProxyTLSSyncDue(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client) bool {
+	if getTLSMode(cr) != storagev1.ProxyTLSModeSelfSigned {
+		return false
+	}
+	ca, err := getSelfSignedCA(ctx, cr, client)
+	if err != nil || ca == nil {
+		return true
+	}
+	caCertSecret := &v1.Secret{}
+	err = client.Get(ctx, types.NamespacedName{Name: CACertSecretName, Namespace: cr.Namespace}, caCertSecret)
+	if err != nil || !isCACertPublished(caCertSecret, ca) {
+		return true
+	}
+	secret := &v1.Secret{}
+	err = client.Get(ctx, types.NamespacedName{Name: getTLSSecretName(cr), Namespace: cr.Namespace}, secret)
+	return err != nil || !isServingCertValid(cr, secret, ca)
+}
+
+// newProxySecret - Returns a secret owned by the proxy
+func newProxySecret(cr *storagev1.CSIPowerMaxRevProxy, name string, secretType v1.SecretType) *v1.Secret {
+	return &v1.Secret{
+		Type: secretType,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"name": ReverseProxyName,
+			},
+			OwnerReferences: getOwnerReferences(cr),
+		},
+		Data: make(map[string][]byte),
+	}
+}
+
+// selfSignedCA - CA generated by the operator in the SelfSigned TLS mode
+type selfSignedCA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// parseCertificatePEM - Parses the first PEM encoded certificate in the data
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parsePrivateKeyPEM - Parses a PEM encoded PKCS #8 private key
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// newSerialNumber - Returns a random serial number for a certificate
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// encodeCertificate - Creates a certificate from the template signed by the parent & returns it PEM encoded
+// along with the PEM encoded private key of the certificate
+func encodeCertificate(template, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer,
+	[]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if parent == nil {
+		// Self-signed certificate
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// generateSelfSignedCA - Generates a new CA for the proxy
+func generateSelfSignedCA(cr *storagev1.CSIPowerMaxRevProxy) (*selfSignedCA, error) {
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s.%s", CASecretName, cr.Namespace)},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedCADuration),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, key, certPEM, keyPEM, err := encodeCertificate(template, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &selfSignedCA{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}, nil
+}
+
+// generateServingCert - Generates a serving certificate for the proxy service signed by the CA
+// Returns the PEM encoded certificate & private key
+func generateServingCert(cr *storagev1.CSIPowerMaxRevProxy, ca *selfSignedCA) ([]byte, []byte, error) {
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	duration, _ := getTLSDurations(cr)
+	dnsNames := getProxyDNSNames(cr)
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: dnsNames[2]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(duration),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	_, _, certPEM, keyPEM, err := encodeCertificate(template, ca.cert, ca.key)
+	return certPEM, keyPEM, err
+}
+
+// getSelfSignedCA - Returns the CA stored in the CA secret of the proxy
+// Returns nil if the secret doesn't exist, doesn't hold a valid CA or if the CA has to be renewed
+func getSelfSignedCA(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client) (*selfSignedCA, error) {
+	secret := &v1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: CASecretName, Namespace: cr.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(secret, cr) {
+		return nil, fmt.Errorf("secret %s already exists & isn't managed by the proxy", CASecretName)
+	}
+	cert, err := parseCertificatePEM(secret.Data[caCertKey])
+	if err != nil || !cert.IsCA {
+		return nil, nil
+	}
+	key, err := parsePrivateKeyPEM(secret.Data[caPrivateKeyKey])
+	if err != nil {
+		return nil, nil
+	}
+	// The CA has to outlive the serving certificates issued by it
+	duration, renewBefore := getTLSDurations(cr)
+	if time.Until(cert.NotAfter) < duration+renewBefore {
+		return nil, nil
+	}
+	return &selfSignedCA{cert: cert, key: key, certPEM: secret.Data[caCertKey], keyPEM: secret.Data[caPrivateKeyKey]}, nil
+}
+
+// isServingCertValid - Returns true if the secret holds a serving certificate for the proxy service
+// which was issued by the CA & doesn't have to be renewed yet
+func isServingCertValid(cr *storagev1.CSIPowerMaxRevProxy, secret *v1.Secret, ca *selfSignedCA) bool {
+	cert, err := parseCertificatePEM(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+	if _, err := parsePrivateKeyPEM(secret.Data[v1.TLSPrivateKeyKey]); err != nil {
+		return false
+	}
+	if !bytes.Equal(secret.Data[caCertKey], ca.certPEM) || cert.CheckSignatureFrom(ca.cert) != nil {
+		return false
+	}
+	if !reflect.DeepEqual(cert.DNSNames, getProxyDNSNames(cr)) {
+		return false
+	}
+	_, renewBefore := getTLSDurations(cr)
+	return time.Until(cert.NotAfter) > renewBefore
+}
+
+// isCACertPublished - Returns true if the secret holds only the certificate of the CA
+func isCACertPublished(secret *v1.Secret, ca *selfSignedCA) bool {
+	return len(secret.Data) == 1 && bytes.Equal(secret.Data[caCertKey], ca.certPEM)
+}
+
+// syncCACertSecret - Publishes the certificate of the CA, without its private key, in the CA certificate secret
+// This secret can be referenced from the certSecrets of the CSIPowerMax driver
+func syncCACertSecret(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, ca *selfSignedCA, client client.Client,
+	reqLogger logr.Logger) error {
+	found := &v1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: CACertSecretName, Namespace: cr.Namespace}, found)
+	if err == nil {
+		if !metav1.IsControlledBy(found, cr) {
+			return fmt.Errorf("secret %s already exists & isn't managed by the proxy", CACertSecretName)
+		}
+		if isCACertPublished(found, ca) {
+			return nil
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	secret := newProxySecret(cr, CACertSecretName, v1.SecretTypeOpaque)
+	secret.Data[caCertKey] = ca.certPEM
+	return secrets.SyncSecret(ctx, secret, client, reqLogger)
+}
+
+// syncSelfSignedTLS - Generates the CA of the proxy & a serving certificate signed by it
+// Both are renewed before their expiry. The serving certificate is reissued whenever the CA is renewed
+func syncSelfSignedTLS(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client, reqLogger logr.Logger) error {
+	ca, err := getSelfSignedCA(ctx, cr, client)
+	if err != nil {
+		return err
+	}
+	if ca == nil {
+		reqLogger.Info("Generating the CA of the proxy", "Secret", CASecretName)
+		ca, err = generateSelfSignedCA(cr)
+		if err != nil {
+			return err
+		}
+		caSecret := newProxySecret(cr, CASecretName, v1.SecretTypeOpaque)
+		caSecret.Data[caCertKey] = ca.certPEM
+		caSecret.Data[caPrivateKeyKey] = ca.keyPEM
+		err = secrets.SyncSecret(ctx, caSecret, client, reqLogger)
+		if err != nil {
+			return err
+		}
+	}
+	err = syncCACertSecret(ctx, cr, ca, client, reqLogger)
+	if err != nil {
+		return err
+	}
+	name := getTLSSecretName(cr)
+	found := &v1.Secret{}
+	err = client.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, found)
+	if err == nil {
+		if !metav1.IsControlledBy(found, cr) {
+			return fmt.Errorf("secret %s already exists & isn't managed by the proxy", name)
+		}
+		if isServingCertValid(cr, found, ca) {
+			return nil
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	reqLogger.Info("Issuing the serving certificate of the proxy", "Secret", name)
+	certPEM, keyPEM, err := generateServingCert(cr, ca)
+	if err != nil {
+		return err
+	}
+	secret := newProxySecret(cr, name, v1.SecretTypeTLS)
+	secret.Data[v1.TLSCertKey] = certPEM
+	secret.Data[v1.TLSPrivateKeyKey] = keyPEM
+	secret.Data[caCertKey] = ca.certPEM
+	return secrets.SyncSecret(ctx, secret, client, reqLogger)
+}
+
+// newCertificateForCR - Returns the cert-manager Certificate issuing the TLS secret of the proxy
+func newCertificateForCR(cr *storagev1.CSIPowerMaxRevProxy) *unstructured.Unstructured {
+	duration, renewBefore := getTLSDurations(cr)
+	issuerRef := cr.Spec.TLS.IssuerRef
+	issuer := map[string]interface{}{
+		"name":  issuerRef.Name,
+		"kind":  "Issuer",
+		"group": certManagerGroup,
+	}
+	if issuerRef.Kind != "" {
+		issuer["kind"] = issuerRef.Kind
+	}
+	if issuerRef.Group != "" {
+		issuer["group"] = issuerRef.Group
+	}
+	dnsNames := make([]interface{}, 0)
+	for _, dnsName := range getProxyDNSNames(cr) {
+		dnsNames = append(dnsNames, dnsName)
+	}
+	certificate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretName":  getTLSSecretName(cr),
+				"commonName":  dnsNames[2],
+				"dnsNames":    dnsNames,
+				"duration":    duration.String(),
+				"renewBefore": renewBefore.String(),
+				"usages":      []interface{}{"digital signature", "key encipherment", "server auth"},
+				"issuerRef":   issuer,
+			},
+		},
+	}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetName(ReverseProxyName)
+	certificate.SetNamespace(cr.Namespace)
+	certificate.SetLabels(map[string]string{"name": ReverseProxyName})
+	certificate.SetOwnerReferences(getOwnerReferences(cr))
+	return certificate
+}
+
+// syncCertManagerCertificate - Creates or updates the cert-manager Certificate issuing the TLS secret of the proxy
+func syncCertManagerCertificate(ctx context.Context, cr *storagev1.CSIPowerMaxRevProxy, client client.Client,
+	reqLogger logr.Logger) error {
+	certificate := newCertificateForCR(cr)
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(certificateGVK)
+	err := client.Get(ctx, types.NamespacedName{Name: certificate.GetName(), Namespace: certificate.GetNamespace()}, found)
+	if errors.IsNotFound(err) {
+		reqLogger.Info("Creating a new cert-manager Certificate", "Namespace", certificate.GetNamespace(),
+			"Name", certificate.GetName())
+		return client.Create(ctx, certificate)
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(found, cr) {
+		return fmt.Errorf("certificate %s already exists & isn't managed by the proxy", found.GetName())
+	}
+	if reflect.DeepEqual(found.Object["spec"], certificate.Object["spec"]) {
+		return nil
+	}
+	reqLogger.Info("Updating the cert-manager Certificate", "Name", found.GetName())
+	found.Object["spec"] = certificate.Object["spec"]
+	return client.Update(ctx, found)
+}
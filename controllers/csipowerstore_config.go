@@ -0,0 +1,167 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// powerStoreBlockProtocols - Block protocols supported by the PowerStore driver
+var powerStoreBlockProtocols = []string{"auto", "FC", "ISCSI", "NVMeTCP", "NVMeFC", "None"}
+
+// powerStoreArrayConfig - Storage array in the config secret of the PowerStore driver
+type powerStoreArrayConfig struct {
+	Endpoint                  string `json:"endpoint"`
+	GlobalID                  string `json:"globalID"`
+	Username                  string `json:"username"`
+	Password                  string `json:"password"`
+	SkipCertificateValidation bool   `json:"skipCertificateValidation,omitempty"`
+	IsDefault                 bool   `json:"isDefault,omitempty"`
+	BlockProtocol             string `json:"blockProtocol,omitempty"`
+	NasName                   string `json:"nasName,omitempty"`
+}
+
+// powerStoreConfig - Content of the config key of the config secret of the PowerStore driver
+type powerStoreConfig struct {
+	Arrays []powerStoreArrayConfig `json:"arrays"`
+}
+
+// hasPowerStoreConfigSecret - Returns true if the driver reads its storage arrays from the config secret
+// Since v1.3.0, the PowerStore driver expects the config to be placed into a secret & mounted to the container
+func hasPowerStoreConfigSecret(instance storagev1.CSIDriver) bool {
+	configVersion := utils.GetConfigVersion(instance)
+	return configVersion != "v1" && configVersion != "v2"
+}
+
+// getPowerStoreConfigSecretName - Returns the name of the config secret of the PowerStore driver
+func getPowerStoreConfigSecretName(instance storagev1.CSIDriver) string {
+	return fmt.Sprintf("%s-config", instance.GetDriverType())
+}
+
+// parsePowerStoreConfig - Parses the config key of the config secret of the PowerStore driver
+func parsePowerStoreConfig(configSecret *v1.Secret) (*powerStoreConfig, error) {
+	configBytes := configSecret.Data["config"]
+	if len(configBytes) == 0 {
+		return nil, fmt.Errorf("secret %s: config key not found", configSecret.Name)
+	}
+	config := &powerStoreConfig{}
+	err := yaml.Unmarshal(configBytes, config)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: unable to parse the config [%v]", configSecret.Name, err)
+	}
+	return config, nil
+}
+
+// validatePowerStoreConfig - Validates the storage arrays in the config secret of the PowerStore driver
+// All the problems found are reported in the returned error
+func validatePowerStoreConfig(secretName string, config *powerStoreConfig) error {
+	reasons := make([]string, 0)
+	if len(config.Arrays) == 0 {
+		reasons = append(reasons, "arrays: no storage array configured")
+	}
+	defaults := 0
+	globalIDs := make(map[string]int)
+	for i, array := range config.Arrays {
+		field := fmt.Sprintf("arrays[%d]", i)
+		if array.GlobalID == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.globalID: must be set", field))
+		} else if j, found := globalIDs[array.GlobalID]; found {
+			reasons = append(reasons, fmt.Sprintf("%s.globalID: duplicate globalID %s (also used by arrays[%d])",
+				field, array.GlobalID, j))
+		} else {
+			globalIDs[array.GlobalID] = i
+		}
+		endpoint, err := url.Parse(array.Endpoint)
+		if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.endpoint: %q isn't a valid URL", field, array.Endpoint))
+		}
+		if array.Username == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.username: must be set", field))
+		}
+		if array.Password == "" {
+			reasons = append(reasons, fmt.Sprintf("%s.password: must be set", field))
+		}
+		if array.BlockProtocol != "" && !isPowerStoreBlockProtocol(array.BlockProtocol) {
+			reasons = append(reasons, fmt.Sprintf("%s.blockProtocol: unsupported protocol %s (supported: %s)",
+				field, array.BlockProtocol, strings.Join(powerStoreBlockProtocols, ", ")))
+		}
+		if array.IsDefault {
+			defaults++
+		}
+	}
+	if len(config.Arrays) != 0 && defaults != 1 {
+		reasons = append(reasons, fmt.Sprintf("arrays: exactly one array must have isDefault: true, found %d", defaults))
+	}
+	if len(reasons) != 0 {
+		return fmt.Errorf("invalid config in secret %s: %s", secretName, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// isPowerStoreBlockProtocol - Returns true if the block protocol is supported by the driver (case insensitive)
+func isPowerStoreBlockProtocol(protocol string) bool {
+	for _, supported := range powerStoreBlockProtocols {
+		if strings.EqualFold(protocol, supported) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateStorageArrays - Validates the config secret of the driver & returns the storage arrays it configures
+func (r *CSIPowerStoreReconciler) ValidateStorageArrays(ctx context.Context, instance storagev1.CSIDriver,
+	log logr.Logger) ([]storagev1.StorageArrayStatus, error) {
+	if !hasPowerStoreConfigSecret(instance) {
+		return nil, nil
+	}
+	secretName := getPowerStoreConfigSecretName(instance)
+	configSecret := &v1.Secret{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.GetNamespace()}, configSecret)
+	if k8serror.IsNotFound(err) {
+		return nil, utils.NewMissingSecretError(secretName,
+			fmt.Errorf("failed to find the config secret: [%s]", secretName))
+	} else if err != nil {
+		log.Error(err, "Failed to query for the config secret. Warning - the driver pods may not start")
+		return instance.GetDriverStatus().StorageArrays, nil
+	}
+	config, err := parsePowerStoreConfig(configSecret)
+	if err != nil {
+		return nil, err
+	}
+	err = validatePowerStoreConfig(secretName, config)
+	if err != nil {
+		return nil, err
+	}
+	arrays := make([]storagev1.StorageArrayStatus, 0, len(config.Arrays))
+	for _, array := range config.Arrays {
+		arrays = append(arrays, storagev1.StorageArrayStatus{
+			ID:        array.GlobalID,
+			Endpoint:  array.Endpoint,
+			IsDefault: array.IsDefault,
+		})
+	}
+	return arrays, nil
+}
@@ -20,6 +20,7 @@ import (
 	"sync/atomic"
 
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 
 	"github.com/dell/dell-csi-operator/pkg/utils"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -40,6 +42,7 @@ type CSIPowerStoreReconciler struct {
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      operatorconfig.Config
+	Recorder    record.EventRecorder
 	updateCount int32
 }
 
@@ -108,6 +111,12 @@ func (r *CSIPowerStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIPowerStore{}, &storagev1.CSIPowerStoreList{}, driverReferenceIndexValues,
+		r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
 
@@ -117,7 +126,9 @@ func (r *CSIPowerStoreReconciler) InitializeDriverSpec(instance storagev1.CSIDri
 }
 
 // ValidateDriverSpec - Make any driver specific validation
-func (r *CSIPowerStoreReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, reqLogger logr.Logger) error {
+func (r *CSIPowerStoreReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
+	// The config secret is validated with the storage arrays (see ValidateStorageArrays)
 	return nil
 }
 
@@ -131,6 +142,11 @@ func (r *CSIPowerStoreReconciler) GetClient() client.Client {
 	return r.Client
 }
 
+// GetEventRecorder - Returns the event recorder
+func (r *CSIPowerStoreReconciler) GetEventRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
 // GetScheme - Returns k8s runtime scheme
 func (r *CSIPowerStoreReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
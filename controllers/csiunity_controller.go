@@ -29,7 +29,9 @@ import (
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -38,6 +40,7 @@ import (
 
 	storagev1 "github.com/dell/dell-csi-operator/api/v1"
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
 )
 
@@ -47,6 +50,7 @@ type CSIUnityReconciler struct {
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      operatorconfig.Config
+	Recorder    record.EventRecorder
 	updateCount int32
 }
 
@@ -113,6 +117,12 @@ func (r *CSIUnityReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIUnity{}, &storagev1.CSIUnityList{}, driverReferenceIndexValues,
+		r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
 
@@ -126,6 +136,11 @@ func (r *CSIUnityReconciler) GetClient() client.Client {
 	return r.Client
 }
 
+// GetEventRecorder - Returns the event recorder
+func (r *CSIUnityReconciler) GetEventRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
 // GetScheme - Returns k8s runtime scheme
 func (r *CSIUnityReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
@@ -164,7 +179,8 @@ func (r *CSIUnityReconciler) InitializeDriverSpec(instance storagev1.CSIDriver,
 }
 
 // ValidateDriverSpec does driver specific validation of the spec
-func (r *CSIUnityReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, reqLogger logr.Logger) error {
+func (r *CSIUnityReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
 	driver := instance.GetDriver()
 
 	err := r.validateMultiArrayUnityCredsSecret(ctx, instance, reqLogger)
@@ -175,7 +191,7 @@ func (r *CSIUnityReconciler) ValidateDriverSpec(ctx context.Context, instance st
 	scs := driver.StorageClass
 	for _, sc := range scs {
 		scParams := sc.Parameters
-		if instance.GetDriverType() == storagev1.Unity && instance.GetDriver().ConfigVersion == "v2" {
+		if instance.GetDriverType() == storagev1.Unity && utils.GetConfigVersion(instance) == "v2" {
 			pool, ok := scParams["storagePool"]
 			if !ok {
 				return fmt.Errorf("storagePool paramter is mandatory in StorageClass [%s]", sc.Name)
@@ -213,7 +229,9 @@ func (r *CSIUnityReconciler) validateMultiArrayUnityCredsSecret(ctx context.Cont
 	client := r.GetClient()
 	secretName := fmt.Sprintf("%s-creds", instance.GetDriverType())
 	credSecret, err := secrets.GetSecret(ctx, secretName, instance.GetNamespace(), client, log)
-	if err != nil {
+	if k8serror.IsNotFound(err) {
+		return utils.NewMissingSecretError(secretName, fmt.Errorf("reading secret [%s] error [%s]", secretName, err))
+	} else if err != nil {
 		return fmt.Errorf("reading secret [%s] error [%s]", secretName, err)
 	}
 
@@ -239,7 +257,7 @@ func (r *CSIUnityReconciler) validateMultiArrayUnityCredsSecret(ctx context.Cont
 	if string(configBytes) != "" {
 		secretConfig := new(StorageArrayList)
 
-		if instance.GetDriver().ConfigVersion == "v4" || instance.GetDriver().ConfigVersion == "v5" {
+		if configVersion := utils.GetConfigVersion(instance); configVersion == "v4" || configVersion == "v5" {
 			err := json.Unmarshal(configBytes, &secretConfig)
 			if err != nil {
 				return fmt.Errorf("Unable to parse the credentials [%v]", err)
@@ -29,7 +29,9 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -38,6 +40,7 @@ import (
 
 	storagev1 "github.com/dell/dell-csi-operator/api/v1"
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
 )
 
@@ -47,6 +50,7 @@ type CSIVXFlexOSReconciler struct {
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	Config      operatorconfig.Config
+	Recorder    record.EventRecorder
 	updateCount int32
 }
 
@@ -93,6 +97,11 @@ func (r *CSIVXFlexOSReconciler) GetClient() client.Client {
 	return r.Client
 }
 
+// GetEventRecorder - Returns the event recorder
+func (r *CSIVXFlexOSReconciler) GetEventRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
 // GetScheme - Returns k8s runtime scheme
 func (r *CSIVXFlexOSReconciler) GetScheme() *runtime.Scheme {
 	return r.Scheme
@@ -130,7 +139,7 @@ func (r *CSIVXFlexOSReconciler) InitializeDriverSpec(instance storagev1.CSIDrive
 	isDriverupdate := false
 	driver := instance.GetDriver()
 	ctx := context.Background()
-	if driver.ConfigVersion == "v2.1.0" {
+	if utils.GetConfigVersion(instance) == "v2.1.0" {
 		var newmdm corev1.EnvVar
 		mdmVar, err := r.GetMDMFromSecret(ctx, instance, reqLogger)
 		if err != nil {
@@ -241,7 +250,8 @@ func Getmdmipforsdc(sidenv []corev1.EnvVar, mdmFin string, reqLogger logr.Logger
 
 // ValidateDriverSpec - Validates the driver spec
 // returns error if the spec is not valid
-func (r *CSIVXFlexOSReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, reqLogger logr.Logger) error {
+func (r *CSIVXFlexOSReconciler) ValidateDriverSpec(ctx context.Context, instance storagev1.CSIDriver, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
 	// Validates the HOST_PID value from manifest file
 	isfound := false
 	driver := instance.GetDriver()
@@ -300,7 +310,9 @@ func (r *CSIVXFlexOSReconciler) GetMDMFromSecret(ctx context.Context, instance s
 	client := r.GetClient()
 	secretName := fmt.Sprintf("%s-config", instance.GetDriverType())
 	credSecret, err := secrets.GetSecret(ctx, secretName, instance.GetNamespace(), client, log)
-	if err != nil {
+	if k8serror.IsNotFound(err) {
+		return "", utils.NewMissingSecretError(secretName, fmt.Errorf("reading secret [%s] error [%s]", secretName, err))
+	} else if err != nil {
 		return "", fmt.Errorf("reading secret [%s] error [%s]", secretName, err)
 	}
 
@@ -416,5 +428,11 @@ func (r *CSIVXFlexOSReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Log.Error(err, "Unable to watch Daemonset")
 		os.Exit(1)
 	}
+	err = watchSecretsAndConfigMaps(c, mgr, &storagev1.CSIVXFlexOS{}, &storagev1.CSIVXFlexOSList{}, driverReferenceIndexValues,
+		r.Config.SecretSourceNamespaces, r.Log)
+	if err != nil {
+		r.Log.Error(err, "Unable to watch Secrets & ConfigMaps")
+		os.Exit(1)
+	}
 	return nil
 }
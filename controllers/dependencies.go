@@ -0,0 +1,43 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dependencyIndexKey - Index of the driver CRs by the objects they are waiting for
+const dependencyIndexKey = ".status.missingDependencies"
+
+// indexMissingDependencies - Indexes the driver CRs (of the type of instance) by their missing dependencies,
+// so that the creation of a dependency requeues exactly the CRs waiting for it
+func indexMissingDependencies(ctx context.Context, mgr ctrl.Manager, instance client.Object) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, instance, dependencyIndexKey, func(obj client.Object) []string {
+		driver, ok := obj.(storagev1.CSIDriver)
+		if !ok {
+			return nil
+		}
+		values := make([]string, 0)
+		for _, dependency := range driver.GetDriverStatus().MissingDependencies {
+			values = append(values, utils.DependencyIndexValue(dependency.Kind, dependency.Name))
+		}
+		return values
+	})
+}
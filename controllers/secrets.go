@@ -0,0 +1,310 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	storagev1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/resources"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// secretIndexKey - Index of the driver workloads by the names of the Secrets referenced by their pod templates
+const secretIndexKey = ".spec.template.secrets"
+
+// IndexSecretReferences - Indexes the Deployments, StatefulSets & DaemonSets by the Secrets referenced by
+// their pod templates. Must be called once before the manager is started
+func IndexSecretReferences(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	err := indexer.IndexField(ctx, &appsv1.Deployment{}, secretIndexKey, func(obj client.Object) []string {
+		return resources.GetReferencedSecrets(obj.(*appsv1.Deployment).Spec.Template.Spec)
+	})
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &appsv1.StatefulSet{}, secretIndexKey, func(obj client.Object) []string {
+		return resources.GetReferencedSecrets(obj.(*appsv1.StatefulSet).Spec.Template.Spec)
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &appsv1.DaemonSet{}, secretIndexKey, func(obj client.Object) []string {
+		return resources.GetReferencedSecrets(obj.(*appsv1.DaemonSet).Spec.Template.Spec)
+	})
+}
+
+// referenceIndexKey - Index of the CRs by the Secrets & ConfigMaps referenced by their spec
+const referenceIndexKey = ".spec.references"
+
+// certSecretSelectorIndexValue - Index value of the driver CRs selecting their certificate Secrets by labels
+const certSecretSelectorIndexValue = "Secret/certSecretSelector"
+
+// numberedCertSecretsRegex - Matches the names of the <driver>-certs-<n> Secrets
+var numberedCertSecretsRegex = regexp.MustCompile("^(.+)-certs-[0-9]+$")
+
+// numberedCertSecretsIndexValue - Returns the index value of the driver CRs using the <driver>-certs-<n> Secrets
+func numberedCertSecretsIndexValue(prefix string) string {
+	return utils.DependencyIndexValue(utils.DependencyKindSecret, prefix+"-certs-*")
+}
+
+// secretRefIndexValues - Returns the index values of a secret reference of the form [namespace/]name
+// A Secret referenced from another namespace is indexed by its source & by its copy in the namespace of the CR
+func secretRefIndexValues(ref, namespace string) []string {
+	values := []string{utils.DependencyIndexValue(utils.DependencyKindSecret, utils.LocalSecretName(ref))}
+	if sourceNamespace, _ := utils.SplitSecretRef(ref); sourceNamespace != "" && sourceNamespace != namespace {
+		values = append(values, utils.DependencyIndexValue(utils.DependencyKindSecret, ref))
+	}
+	return values
+}
+
+// driverReferenceIndexValues - Returns the index values of the Secrets & ConfigMaps referenced by the driver spec
+func driverReferenceIndexValues(obj client.Object) []string {
+	driver, ok := obj.(storagev1.CSIDriver)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0)
+	for _, ref := range utils.GetDriverSecretRefs(driver) {
+		values = append(values, secretRefIndexValues(ref, driver.GetNamespace())...)
+	}
+	spec := driver.GetDriver()
+	if bundle := spec.TrustBundle; bundle != nil && bundle.ConfigMapKeyRef != nil {
+		values = append(values, utils.DependencyIndexValue(utils.DependencyKindConfigMap, bundle.ConfigMapKeyRef.Name))
+	}
+	if spec.CertSecretSelector != nil {
+		values = append(values, certSecretSelectorIndexValue)
+	} else if len(spec.CertSecrets) == 0 &&
+		(driver.GetDriverType() == storagev1.Unity || driver.GetDriverType() == storagev1.Isilon) {
+		values = append(values, numberedCertSecretsIndexValue(string(driver.GetDriverType())))
+	}
+	return values
+}
+
+// proxyReferenceIndexValues - Returns the index values of the Secrets & ConfigMaps referenced by the proxy spec
+func proxyReferenceIndexValues(obj client.Object) []string {
+	proxy, ok := obj.(*storagev1.CSIPowerMaxRevProxy)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0)
+	for _, ref := range getProxySecretRefs(proxy) {
+		values = append(values, secretRefIndexValues(ref, proxy.Namespace)...)
+	}
+	if bundle := proxy.Spec.TrustBundle; bundle != nil && bundle.ConfigMapKeyRef != nil {
+		values = append(values, utils.DependencyIndexValue(utils.DependencyKindConfigMap, bundle.ConfigMapKeyRef.Name))
+	}
+	return values
+}
+
+// referenceHandler - Maps the events of the Secrets & ConfigMaps to the CRs (of a single kind) depending on them
+type referenceHandler struct {
+	client            client.Client
+	ownerKind         string
+	list              client.ObjectList
+	allowedNamespaces []string
+	log               logr.Logger
+}
+
+// watchSecretsAndConfigMaps - Indexes the CRs (of the type of instance) by the Secrets & ConfigMaps referenced by
+// their spec & by their missing dependencies, then watches the Secrets & ConfigMaps. An event requeues the CRs which:
+//   - own a workload whose pod template references the Secret (the pod templates are stamped with a checksum of
+//     the Secrets, so a rotation of a Secret results in a rollout of the pods)
+//   - are waiting for the object as a missing dependency
+//   - reference the object from their spec, directly, through the certSecretSelector, as one of the numbered
+//     <driver>-certs-<n> Secrets or as the source of a Secret mirrored from an allowlisted namespace
+func watchSecretsAndConfigMaps(c controller.Controller, mgr ctrl.Manager, instance client.Object, list client.ObjectList,
+	referenceIndexValues client.IndexerFunc, allowedNamespaces []string, log logr.Logger) error {
+	ctx := context.Background()
+	err := indexMissingDependencies(ctx, mgr, instance)
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(ctx, instance, referenceIndexKey, referenceIndexValues)
+	if err != nil {
+		return err
+	}
+	gvk, err := apiutil.GVKForObject(instance, mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	h := &referenceHandler{
+		client:            mgr.GetClient(),
+		ownerKind:         gvk.Kind,
+		list:              list,
+		allowedNamespaces: allowedNamespaces,
+		log:               log,
+	}
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(h.mapSecret))
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(h.mapConfigMap))
+}
+
+// mapSecret - Returns the requests for the CRs depending on the Secret
+func (h *referenceHandler) mapSecret(secret client.Object) []reconcile.Request {
+	requests := newRequestSet()
+	h.addOwnersOfReferencingWorkloads(requests, secret)
+	h.addDependentCRs(requests, utils.DependencyKindSecret, secret)
+	for _, cr := range h.listCRs(client.InNamespace(secret.GetNamespace()),
+		client.MatchingFields{referenceIndexKey: certSecretSelectorIndexValue}) {
+		driver, ok := cr.(storagev1.CSIDriver)
+		if !ok {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(driver.GetDriver().CertSecretSelector)
+		if err == nil && selector.Matches(labels.Set(secret.GetLabels())) {
+			requests.add(cr, "Certificate Secret changed", "Secret", secret.GetName(), h.log)
+		}
+	}
+	if match := numberedCertSecretsRegex.FindStringSubmatch(secret.GetName()); match != nil {
+		for _, cr := range h.listCRs(client.InNamespace(secret.GetNamespace()),
+			client.MatchingFields{referenceIndexKey: numberedCertSecretsIndexValue(match[1])}) {
+			requests.add(cr, "Certificate Secret changed", "Secret", secret.GetName(), h.log)
+		}
+	}
+	if h.isAllowedSourceNamespace(secret.GetNamespace()) {
+		ref := fmt.Sprintf("%s/%s", secret.GetNamespace(), secret.GetName())
+		for _, cr := range h.listCRs(client.MatchingFields{
+			referenceIndexKey: utils.DependencyIndexValue(utils.DependencyKindSecret, ref),
+		}) {
+			requests.add(cr, "Mirrored Secret changed", "Secret", ref, h.log)
+		}
+	}
+	return requests.requests
+}
+
+// mapConfigMap - Returns the requests for the CRs depending on the ConfigMap
+func (h *referenceHandler) mapConfigMap(configMap client.Object) []reconcile.Request {
+	requests := newRequestSet()
+	h.addDependentCRs(requests, utils.DependencyKindConfigMap, configMap)
+	return requests.requests
+}
+
+// addOwnersOfReferencingWorkloads - Adds the CRs owning a workload whose pod template references the Secret
+func (h *referenceHandler) addOwnersOfReferencingWorkloads(requests *requestSet, secret client.Object) {
+	lists := []client.ObjectList{&appsv1.DeploymentList{}, &appsv1.StatefulSetList{}, &appsv1.DaemonSetList{}}
+	for _, list := range lists {
+		err := h.client.List(context.Background(), list, client.InNamespace(secret.GetNamespace()),
+			client.MatchingFields{secretIndexKey: secret.GetName()})
+		if err != nil {
+			h.log.Error(err, "Failed to list the workloads referencing the Secret", "Secret", secret.GetName())
+			continue
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			continue
+		}
+		for _, object := range objects {
+			workload, ok := object.(metav1.Object)
+			if !ok {
+				continue
+			}
+			owner := metav1.GetControllerOf(workload)
+			if owner == nil || owner.Kind != h.ownerKind {
+				continue
+			}
+			requests.addName(types.NamespacedName{Name: owner.Name, Namespace: workload.GetNamespace()},
+				"Referenced Secret changed", "Secret", secret.GetName(), h.log)
+		}
+	}
+}
+
+// addDependentCRs - Adds the CRs in the namespace of the object which reference it from their spec or wait for it
+func (h *referenceHandler) addDependentCRs(requests *requestSet, kind string, object client.Object) {
+	value := utils.DependencyIndexValue(kind, object.GetName())
+	for _, cr := range h.listCRs(client.InNamespace(object.GetNamespace()),
+		client.MatchingFields{dependencyIndexKey: value}) {
+		requests.add(cr, "Missing dependency is available", kind, object.GetName(), h.log)
+	}
+	for _, cr := range h.listCRs(client.InNamespace(object.GetNamespace()),
+		client.MatchingFields{referenceIndexKey: value}) {
+		requests.add(cr, "Referenced object changed", kind, object.GetName(), h.log)
+	}
+}
+
+// listCRs - Lists the CRs matching the options
+func (h *referenceHandler) listCRs(opts ...client.ListOption) []client.Object {
+	crs := h.list.DeepCopyObject().(client.ObjectList)
+	err := h.client.List(context.Background(), crs, opts...)
+	if err != nil {
+		h.log.Error(err, "Failed to list the CRs")
+		return nil
+	}
+	items, err := meta.ExtractList(crs)
+	if err != nil {
+		return nil
+	}
+	objects := make([]client.Object, 0, len(items))
+	for _, item := range items {
+		if object, ok := item.(client.Object); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// isAllowedSourceNamespace - Returns true if Secrets can be mirrored from the namespace
+func (h *referenceHandler) isAllowedSourceNamespace(namespace string) bool {
+	for _, allowed := range h.allowedNamespaces {
+		if allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// requestSet - Reconcile requests without duplicates
+type requestSet struct {
+	found    map[types.NamespacedName]bool
+	requests []reconcile.Request
+}
+
+// newRequestSet - Returns an empty set of requests
+func newRequestSet() *requestSet {
+	return &requestSet{found: make(map[types.NamespacedName]bool), requests: make([]reconcile.Request, 0)}
+}
+
+// add - Adds the request for the CR
+func (s *requestSet) add(cr client.Object, msg, kind, name string, log logr.Logger) {
+	s.addName(types.NamespacedName{Name: cr.GetName(), Namespace: cr.GetNamespace()}, msg, kind, name, log)
+}
+
+// addName - Adds the request for the named CR
+func (s *requestSet) addName(name types.NamespacedName, msg, kind, objectName string, log logr.Logger) {
+	if s.found[name] {
+		return
+	}
+	s.found[name] = true
+	log.Info(msg, kind, objectName, "CR", name)
+	s.requests = append(s.requests, reconcile.Request{NamespacedName: name})
+}
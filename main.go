@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -200,9 +201,34 @@ func getOperatorConfig() operatorconfig.Config {
 		}
 	}
 	cfg.EnabledDrivers = enabledDrivers
+	// Get the default retry policy for the drivers
+	cfg.RetryInterval = getDurationFromEnv("X_CSI_OPERATOR_RETRY_INTERVAL", constants.DefaultRetryInterval)
+	cfg.MaxRetryDuration = getDurationFromEnv("X_CSI_OPERATOR_MAX_RETRY_DURATION", constants.DefaultMaxRetryDuration)
+	// Get the namespaces from which secrets can be mirrored
+	secretSourceNamespaces := make([]string, 0)
+	for _, namespace := range strings.Split(os.Getenv("X_CSI_OPERATOR_SECRET_SOURCE_NAMESPACES"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			secretSourceNamespaces = append(secretSourceNamespaces, namespace)
+		}
+	}
+	cfg.SecretSourceNamespaces = secretSourceNamespaces
 	return cfg
 }
 
+// getDurationFromEnv - Returns the duration set in the environment variable or the default value
+func getDurationFromEnv(envName string, defaultValue time.Duration) time.Duration {
+	envValue := os.Getenv(envName)
+	if envValue == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(envValue)
+	if err != nil || duration <= 0 {
+		log.Info(fmt.Sprintf("Invalid value %s specified for %s. Using the default value %v", envValue, envName, defaultValue))
+		return defaultValue
+	}
+	return duration
+}
+
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
@@ -234,56 +260,67 @@ func main() {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+	if err = controllers.IndexSecretReferences(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to index the Secrets referenced by the driver workloads")
+		os.Exit(1)
+	}
 
 	if err = (&controllers.CSIPowerMaxReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIPowerMax"),
-		Scheme: mgr.GetScheme(),
-		Config: operatorConfig,
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIPowerMax"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIPowerMax"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIPowerMax")
 		os.Exit(1)
 	}
 	if err = (&controllers.CSIPowerMaxRevProxyReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIPowerMaxRevProxy"),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIPowerMaxRevProxy"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIPowerMaxRevProxy"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIPowerMaxRevProxy")
 		os.Exit(1)
 	}
 	if err = (&controllers.CSIIsilonReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIIsilon"),
-		Scheme: mgr.GetScheme(),
-		Config: operatorConfig,
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIIsilon"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIIsilon"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIIsilon")
 		os.Exit(1)
 	}
 	if err = (&controllers.CSIUnityReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIUnity"),
-		Scheme: mgr.GetScheme(),
-		Config: operatorConfig,
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIUnity"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIUnity"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIUnity")
 		os.Exit(1)
 	}
 	if err = (&controllers.CSIVXFlexOSReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIVXFlexOS"),
-		Scheme: mgr.GetScheme(),
-		Config: operatorConfig,
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIVXFlexOS"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIVXFlexOS"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIVXFlexOS")
 		os.Exit(1)
 	}
 	if err = (&controllers.CSIPowerStoreReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("CSIPowerStore"),
-		Scheme: mgr.GetScheme(),
-		Config: operatorConfig,
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIPowerStore"),
+		Scheme:   mgr.GetScheme(),
+		Config:   operatorConfig,
+		Recorder: mgr.GetEventRecorderFor("CSIPowerStore"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CSIPowerStore")
 		os.Exit(1)
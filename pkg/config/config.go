@@ -14,7 +14,11 @@
 
 package config
 
-import csiv1 "github.com/dell/dell-csi-operator/api/v1"
+import (
+	"time"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+)
 
 // DriverType - Represents the type of the driver
 type DriverType string
@@ -37,6 +41,12 @@ type Config struct {
 	EnabledDrivers       []csiv1.DriverType
 	RetryCount           int32
 	IsOpenShift          bool
+	// RetryInterval & MaxRetryDuration are the default retry policy for the drivers
+	RetryInterval    time.Duration
+	MaxRetryDuration time.Duration
+	// SecretSourceNamespaces is the allowlist of namespaces from which secrets can be referenced
+	// as namespace/name in the driver & proxy specs
+	SecretSourceNamespaces []string
 }
 
 // GetDriverType - gets the driver type from a string
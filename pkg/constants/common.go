@@ -23,17 +23,17 @@ import (
 
 // Constants for driver states etc
 const (
-	RetryCount           = 3
-	Running              = csiv1.DriverState("Running")
-	Succeeded            = csiv1.DriverState("Succeeded")
-	Creating             = csiv1.DriverState("Creating")
-	Failed               = csiv1.DriverState("Failed")
-	InvalidConfig        = csiv1.DriverState("InvalidConfig")
-	NoState              = csiv1.DriverState("")
-	Updating             = csiv1.DriverState("Updating")
-	DefaultRetryInterval = 5 * time.Second
-	MaxRetryInterval     = 10 * time.Minute
-	MaxRetryDuration     = 30 * time.Minute
+	RetryCount              = 3
+	Running                 = csiv1.DriverState("Running")
+	Succeeded               = csiv1.DriverState("Succeeded")
+	Creating                = csiv1.DriverState("Creating")
+	Failed                  = csiv1.DriverState("Failed")
+	InvalidConfig           = csiv1.DriverState("InvalidConfig")
+	NoState                 = csiv1.DriverState("")
+	Updating                = csiv1.DriverState("Updating")
+	DefaultRetryInterval    = 5 * time.Second
+	MaxRetryInterval        = 10 * time.Minute
+	DefaultMaxRetryDuration = 30 * time.Minute
 )
 
 // DriverReplicas - Replica count for controller
@@ -57,5 +57,102 @@ const DriverMountPath = "/var/run/csi"
 // DriverMountName - Socket directory volume mount name
 const DriverMountName = "socket-dir"
 
+// PodTemplateChecksumKey - Annotation on pod templates which changes whenever the pod spec, the content
+// of any referenced ConfigMap or the checksum of the referenced Secrets changes, triggering a rollout
+const PodTemplateChecksumKey = "storage.dell.com/config-checksum"
+
+// PodTemplateRestartedAtKey - Annotation on pod templates set to the restart nonce of the driver
+// Any change to the nonce results in a rollout of the driver pods
+const PodTemplateRestartedAtKey = "storage.dell.com/restartedAt"
+
+// PodTemplateSecretsChecksumKey - Annotation on pod templates which changes whenever the content
+// of any Secret referenced by the pod spec changes, triggering a rollout
+const PodTemplateSecretsChecksumKey = "storage.dell.com/secrets-checksum"
+
+// PodTemplateTrustBundleChecksumKey - Annotation on the pod template of the reverse proxy which changes whenever
+// the content of its trust bundle changes, triggering a rollout as the proxy only reads the bundle at startup
+const PodTemplateTrustBundleChecksumKey = "storage.dell.com/trust-bundle-checksum"
+
+// MirroredFromAnnotation - Annotation on the copies of the secrets referenced from other namespaces
+// set to the namespace/name of the source secret
+const MirroredFromAnnotation = "storage.dell.com/mirrored-from"
+
+// CHAPRotateAnnotation - Annotation on the CR. Any change to its value rotates the generated CHAP secret
+const CHAPRotateAnnotation = "storage.dell.com/rotate-chap"
+
+// CHAPRotatedAnnotation - Annotation on the generated CHAP secret set to the value of the rotate annotation
+// of the CR at the time the CHAP secret was generated
+const CHAPRotatedAnnotation = "storage.dell.com/chap-rotated"
+
 // TerminationGracePeriodSeconds - grace period in seconds
 var TerminationGracePeriodSeconds = int64(30)
+
+// Condition types recorded in the driver status
+const (
+	// ConditionAutoUpgrade - condition recorded when the operator upgrades the driver config version
+	ConditionAutoUpgrade = "AutoUpgrade"
+	// ConditionRollback - condition recorded when a rollback of the driver spec is requested
+	ConditionRollback = "Rollback"
+	// ConditionUpgradeCompatibility - condition recorded when the config version of the driver is changed
+	ConditionUpgradeCompatibility = "UpgradeCompatibility"
+	// ConditionDeprecatedEnvs - condition recorded when deprecated environment variables are found in the driver spec
+	ConditionDeprecatedEnvs = "DeprecatedEnvs"
+	// ConditionCertificateExpiry - condition recorded when a certificate mounted in the pods is about to expire
+	ConditionCertificateExpiry = "CertificateExpiry"
+	// ConditionChannelBlocked - condition recorded when the channel followed by the driver can't be reached
+	// through the upgrade paths
+	ConditionChannelBlocked = "ChannelBlocked"
+)
+
+// Reasons for the events & conditions recorded by the operator
+const (
+	// ReasonConfigVersionUpgraded - config version was upgraded by the operator
+	ReasonConfigVersionUpgraded = "ConfigVersionUpgraded"
+	// ReasonRollbackSucceeded - driver spec was restored from the revision history
+	ReasonRollbackSucceeded = "RollbackSucceeded"
+	// ReasonRollbackFailed - driver spec couldn't be restored from the revision history
+	ReasonRollbackFailed = "RollbackFailed"
+	// ReasonCanaryPromoted - canary of the Node plugin was promoted to the driver spec
+	ReasonCanaryPromoted = "CanaryPromoted"
+	// ReasonNodeRolloutBatch - a batch of node pods was restarted by the operator
+	ReasonNodeRolloutBatch = "NodeRolloutBatch"
+	// ReasonNodeRolloutHalted - rollout of the node pods was stopped because of a failed node pod
+	ReasonNodeRolloutHalted = "NodeRolloutHalted"
+	// ReasonNodeRolloutCompleted - all the node pods were restarted by the operator
+	ReasonNodeRolloutCompleted = "NodeRolloutCompleted"
+	// ReasonUpdateDeferred - change to the driver spec was held back till the next maintenance window
+	ReasonUpdateDeferred = "UpdateDeferred"
+	// ReasonUpdateAppliedNow - change to the driver spec was applied outside of the maintenance windows on request
+	ReasonUpdateAppliedNow = "UpdateAppliedNow"
+	// ReasonUpgradeCompatible - driver spec doesn't need any changes for the new config version
+	ReasonUpgradeCompatible = "UpgradeCompatible"
+	// ReasonUpgradeIncompatible - driver spec has to be changed for the new config version
+	ReasonUpgradeIncompatible = "UpgradeIncompatible"
+	// ReasonCompatibilityUnknown - driver configs of the config versions couldn't be compared
+	ReasonCompatibilityUnknown = "CompatibilityUnknown"
+	// ReasonDeprecatedEnvs - deprecated environment variables were renamed in the driver spec
+	ReasonDeprecatedEnvs = "DeprecatedEnvs"
+	// ReasonConfigVersionResolved - config version alias in the driver spec resolved to a new config version
+	ReasonConfigVersionResolved = "ConfigVersionResolved"
+	// ReasonChannelBlocked - none of the config versions of the channel can be reached through the upgrade paths
+	ReasonChannelBlocked = "ChannelBlocked"
+	// ReasonCertificateExpiring - a certificate mounted in the pods expires within one of the thresholds
+	ReasonCertificateExpiring = "CertificateExpiring"
+	// ReasonCertificateExpired - a certificate mounted in the pods has expired
+	ReasonCertificateExpired = "CertificateExpired"
+	// ReasonCertificatesValid - none of the certificates mounted in the pods expire within the thresholds
+	ReasonCertificatesValid = "CertificatesValid"
+	// ReasonCHAPSecretRotated - CHAP secret generated by the operator was rotated
+	ReasonCHAPSecretRotated = "CHAPSecretRotated"
+	// ReasonDriverImageMismatch - tag of the driver image specified in the spec doesn't match the config version
+	ReasonDriverImageMismatch = "DriverImageMismatch"
+)
+
+// DefaultCertExpiryThresholds - Times before the expiry of a certificate at which a warning is reported
+var DefaultCertExpiryThresholds = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+
+// CertExpiryCheckInterval - Max interval between two checks of the expiry of the certificates
+const CertExpiryCheckInterval = 24 * time.Hour
+
+// DriverRevisionHistoryLimit - Max number of driver specs stored in the revision history
+const DriverRevisionHistoryLimit = 5
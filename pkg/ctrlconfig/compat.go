@@ -0,0 +1,376 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ctrlconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// VolumeRename - Volume which has a different name in the target driver config
+type VolumeRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// EnvRename - Environment variable which is replaced by a new name in the target driver config
+type EnvRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CompatibilityReport - Differences between the driver configs of two config versions
+type CompatibilityReport struct {
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+	// FromK8sVersion is the K8s version of the driver config of the previous config version
+	// Only set if the previous config version isn't supported on the K8s version of the cluster
+	FromK8sVersion csiv1.K8sVersion `json:"fromK8sVersion,omitempty"`
+	// AddedEnvs & RemovedEnvs are the driver environment variables added/removed in the target
+	AddedEnvs   []string `json:"addedEnvs,omitempty"`
+	RemovedEnvs []string `json:"removedEnvs,omitempty"`
+	// RenamedEnvs are the environment variables which were replaced by a new name in the target
+	RenamedEnvs []EnvRename `json:"renamedEnvs,omitempty"`
+	// NewMandatoryEnvs are the environment variables which are mandatory only in the target
+	NewMandatoryEnvs []string `json:"newMandatoryEnvs,omitempty"`
+	// RenamedVolumes are the volumes with the same source but a different name in the target
+	RenamedVolumes []VolumeRename `json:"renamedVolumes,omitempty"`
+	AddedVolumes   []string       `json:"addedVolumes,omitempty"`
+	RemovedVolumes []string       `json:"removedVolumes,omitempty"`
+	// AddedSidecars & RemovedSidecars are the sidecars added/removed in the target
+	AddedSidecars   []string `json:"addedSidecars,omitempty"`
+	RemovedSidecars []string `json:"removedSidecars,omitempty"`
+	// NewMandatorySidecars are the sidecars which are mandatory only in the target
+	NewMandatorySidecars []string `json:"newMandatorySidecars,omitempty"`
+	// NewMandatoryStorageClassParams are the storage class params which are mandatory only in the target
+	NewMandatoryStorageClassParams []string `json:"newMandatoryStorageClassParams,omitempty"`
+	// RBACChanges are the changes to the cluster roles of the driver
+	RBACChanges []string `json:"rbacChanges,omitempty"`
+	// UnsupportedEnvs are the environment variables set in the driver spec which were removed in the target
+	UnsupportedEnvs []string `json:"unsupportedEnvs,omitempty"`
+	// MissingMandatoryEnvs are the new mandatory environment variables which are not set in the driver spec
+	MissingMandatoryEnvs []string `json:"missingMandatoryEnvs,omitempty"`
+}
+
+// GetDriverConfigFileName - Returns the name of the driver config file for a config version & K8s version
+func GetDriverConfigFileName(driverType csiv1.DriverType, configVersion string, k8sVersion csiv1.K8sVersion) string {
+	return fmt.Sprintf("%s_%s_%s", string(driverType), strings.Replace(configVersion, ".", "", -1), k8sVersion)
+}
+
+// ReadDriverConfig - Reads the driver config for a config version & K8s version
+// If the config version is not supported on the K8s version, the config for the latest K8s version is read
+// Returns the K8s version of the driver config which was read
+func ReadDriverConfig(configDirectory string, driverType csiv1.DriverType, configVersion string,
+	k8sVersion csiv1.K8sVersion) (*DriverConfig, csiv1.K8sVersion, error) {
+	driverVersion := GetDriverConfigFileName(driverType, configVersion, k8sVersion)
+	if _, err := os.Stat(filepath.Join(configDirectory, fmt.Sprintf("%s.json", driverVersion))); err != nil {
+		pattern := fmt.Sprintf("%s.json", GetDriverConfigFileName(driverType, configVersion, "*"))
+		matches, _ := filepath.Glob(filepath.Join(configDirectory, pattern))
+		if len(matches) == 0 {
+			return nil, "", fmt.Errorf("no driver config found for %s %s", driverType, configVersion)
+		}
+		sort.Strings(matches)
+		driverVersion = strings.TrimSuffix(filepath.Base(matches[len(matches)-1]), ".json")
+		k8sVersion = csiv1.K8sVersion(strings.TrimPrefix(driverVersion,
+			GetDriverConfigFileName(driverType, configVersion, "")))
+	}
+	driverConfig, err := readConfig(configDirectory, driverVersion, log)
+	if err != nil {
+		return nil, "", err
+	}
+	return &driverConfig, k8sVersion, nil
+}
+
+// CompareDriverConfigs - Returns a report of the differences between two driver configs
+func CompareDriverConfigs(fromVersion string, from *DriverConfig, toVersion string, to *DriverConfig) *CompatibilityReport {
+	report := &CompatibilityReport{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	}
+	fromEnvs := make(map[string]DriverEnv)
+	for _, env := range from.DriverEnvs {
+		if !env.Removed {
+			fromEnvs[env.Name] = env
+		}
+	}
+	toEnvs := make(map[string]DriverEnv)
+	for _, env := range to.DriverEnvs {
+		if env.Removed {
+			continue
+		}
+		toEnvs[env.Name] = env
+		fromEnv, ok := fromEnvs[env.Name]
+		if !ok {
+			report.AddedEnvs = append(report.AddedEnvs, env.Name)
+		}
+		if env.Mandatory && !fromEnv.Mandatory {
+			report.NewMandatoryEnvs = append(report.NewMandatoryEnvs, env.Name)
+		}
+	}
+	replacements := to.GetEnvReplacements()
+	for _, env := range from.DriverEnvs {
+		if _, ok := toEnvs[env.Name]; ok || env.Removed {
+			continue
+		}
+		if replacement := replacements[env.Name]; replacement != "" {
+			report.RenamedEnvs = append(report.RenamedEnvs, EnvRename{From: env.Name, To: replacement})
+		} else {
+			report.RemovedEnvs = append(report.RemovedEnvs, env.Name)
+		}
+	}
+
+	report.RenamedVolumes, report.AddedVolumes, report.RemovedVolumes = compareVolumes(from.getAllVolumes(), to.getAllVolumes())
+
+	fromSidecars := make(map[csiv1.ImageType]SidecarParams)
+	for _, sidecar := range from.SidecarParams {
+		fromSidecars[sidecar.Name] = sidecar
+	}
+	toSidecars := make(map[csiv1.ImageType]bool)
+	for _, sidecar := range to.SidecarParams {
+		toSidecars[sidecar.Name] = true
+		fromSidecar, ok := fromSidecars[sidecar.Name]
+		if !ok {
+			report.AddedSidecars = append(report.AddedSidecars, string(sidecar.Name))
+		}
+		if !sidecar.Optional && (!ok || fromSidecar.Optional) {
+			report.NewMandatorySidecars = append(report.NewMandatorySidecars, string(sidecar.Name))
+		}
+	}
+	for _, sidecar := range from.SidecarParams {
+		if !toSidecars[sidecar.Name] {
+			report.RemovedSidecars = append(report.RemovedSidecars, string(sidecar.Name))
+		}
+	}
+
+	fromParams := make(map[string]bool)
+	for _, param := range from.StorageClassParams {
+		fromParams[param.Name] = param.Mandatory
+	}
+	for _, param := range to.StorageClassParams {
+		if param.Mandatory && !fromParams[param.Name] {
+			report.NewMandatoryStorageClassParams = append(report.NewMandatoryStorageClassParams, param.Name)
+		}
+	}
+
+	return report
+}
+
+// policyRuleKey - Resource (or resource name) covered by a policy rule
+type policyRuleKey struct {
+	apiGroup, resource, resourceName string
+}
+
+// String - Returns the resource as <group>/<resource>[/<name>]
+func (key policyRuleKey) String() string {
+	resource := key.resource
+	if key.apiGroup != "" {
+		resource = fmt.Sprintf("%s/%s", key.apiGroup, resource)
+	}
+	if key.resourceName != "" {
+		resource = fmt.Sprintf("%s/%s", resource, key.resourceName)
+	}
+	return resource
+}
+
+// getPolicyRuleVerbs - Returns the verbs granted by the policy rules for every resource
+func getPolicyRuleVerbs(rules []rbacv1.PolicyRule) map[policyRuleKey]map[string]bool {
+	verbs := make(map[policyRuleKey]map[string]bool)
+	for _, rule := range rules {
+		resourceNames := rule.ResourceNames
+		if len(resourceNames) == 0 {
+			resourceNames = []string{""}
+		}
+		for _, apiGroup := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, resourceName := range resourceNames {
+					key := policyRuleKey{apiGroup: apiGroup, resource: resource, resourceName: resourceName}
+					if verbs[key] == nil {
+						verbs[key] = make(map[string]bool)
+					}
+					for _, verb := range rule.Verbs {
+						verbs[key][verb] = true
+					}
+				}
+			}
+		}
+	}
+	return verbs
+}
+
+// diffPolicyRuleVerbs - Returns the verbs granted by the first set of rules but not by the second one
+// as a sorted list of "<verbs> on <resource>"
+func diffPolicyRuleVerbs(from, to map[policyRuleKey]map[string]bool) []string {
+	var diff []string
+	for key, fromVerbs := range from {
+		var verbs []string
+		for verb := range fromVerbs {
+			if !to[key][verb] {
+				verbs = append(verbs, verb)
+			}
+		}
+		if len(verbs) != 0 {
+			sort.Strings(verbs)
+			diff = append(diff, fmt.Sprintf("%s on %s", strings.Join(verbs, ","), key))
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// CompareClusterRoles - Records the permissions added to & removed from a cluster role of the driver
+func (report *CompatibilityReport) CompareClusterRoles(role string, from, to []rbacv1.PolicyRule) {
+	fromVerbs := getPolicyRuleVerbs(from)
+	toVerbs := getPolicyRuleVerbs(to)
+	for _, added := range diffPolicyRuleVerbs(toVerbs, fromVerbs) {
+		report.RBACChanges = append(report.RBACChanges, fmt.Sprintf("%s cluster role: added %s", role, added))
+	}
+	for _, removed := range diffPolicyRuleVerbs(fromVerbs, toVerbs) {
+		report.RBACChanges = append(report.RBACChanges, fmt.Sprintf("%s cluster role: removed %s", role, removed))
+	}
+}
+
+// getAllVolumes - Returns the volumes of both the controller & node plugins
+func (driverConfig *DriverConfig) getAllVolumes() []corev1.Volume {
+	volumes := make([]corev1.Volume, 0, len(driverConfig.ControllerVolumes)+len(driverConfig.NodeVolumes))
+	volumes = append(volumes, driverConfig.ControllerVolumes...)
+	return append(volumes, driverConfig.NodeVolumes...)
+}
+
+// compareVolumes - Returns the renamed, added & removed volumes
+// A volume is considered renamed if a volume with the same source exists with a different name
+func compareVolumes(from, to []corev1.Volume) ([]VolumeRename, []string, []string) {
+	var renamed []VolumeRename
+	var added, removed []string
+	fromVolumes := make(map[string]corev1.Volume)
+	for _, volume := range from {
+		fromVolumes[volume.Name] = volume
+	}
+	toVolumes := make(map[string]corev1.Volume)
+	for _, volume := range to {
+		toVolumes[volume.Name] = volume
+	}
+	renamedFrom := make(map[string]bool)
+	for _, volume := range to {
+		if _, ok := fromVolumes[volume.Name]; ok {
+			continue
+		}
+		isRenamed := false
+		for _, fromVolume := range from {
+			if _, ok := toVolumes[fromVolume.Name]; ok || renamedFrom[fromVolume.Name] {
+				continue
+			}
+			if reflect.DeepEqual(fromVolume.VolumeSource, volume.VolumeSource) {
+				renamed = append(renamed, VolumeRename{From: fromVolume.Name, To: volume.Name})
+				renamedFrom[fromVolume.Name] = true
+				isRenamed = true
+				break
+			}
+		}
+		if !isRenamed {
+			added = appendIfMissing(added, volume.Name)
+		}
+	}
+	for _, volume := range from {
+		if _, ok := toVolumes[volume.Name]; !ok && !renamedFrom[volume.Name] {
+			removed = appendIfMissing(removed, volume.Name)
+		}
+	}
+	return renamed, added, removed
+}
+
+func appendIfMissing(slice []string, str string) []string {
+	for _, ele := range slice {
+		if ele == str {
+			return slice
+		}
+	}
+	return append(slice, str)
+}
+
+// CheckDriverSpec - Flags the environment variables set in the driver spec which were removed in the target
+// and the new mandatory environment variables which are not set in the driver spec
+func (report *CompatibilityReport) CheckDriverSpec(driver *csiv1.Driver) {
+	specEnvs := make(map[string]bool)
+	for _, template := range []csiv1.ContainerTemplate{driver.Common, driver.Controller, driver.Node.ContainerTemplate} {
+		for _, env := range template.Envs {
+			specEnvs[env.Name] = true
+		}
+	}
+	report.UnsupportedEnvs = nil
+	for _, envName := range report.RemovedEnvs {
+		if specEnvs[envName] {
+			report.UnsupportedEnvs = append(report.UnsupportedEnvs, envName)
+		}
+	}
+	report.MissingMandatoryEnvs = nil
+	for _, envName := range report.NewMandatoryEnvs {
+		if !specEnvs[envName] {
+			report.MissingMandatoryEnvs = append(report.MissingMandatoryEnvs, envName)
+		}
+	}
+}
+
+// IsCompatible - Returns false if the driver spec has to be changed for the target config version
+func (report *CompatibilityReport) IsCompatible() bool {
+	return len(report.UnsupportedEnvs) == 0 && len(report.MissingMandatoryEnvs) == 0
+}
+
+// String - Returns a human readable summary of the report
+func (report *CompatibilityReport) String() string {
+	var changes []string
+	add := func(description string, items []string) {
+		if len(items) != 0 {
+			changes = append(changes, fmt.Sprintf("%s: %s", description, strings.Join(items, ", ")))
+		}
+	}
+	add("unsupported envs set in spec", report.UnsupportedEnvs)
+	add("missing mandatory envs", report.MissingMandatoryEnvs)
+	add("added envs", report.AddedEnvs)
+	add("removed envs", report.RemovedEnvs)
+	renamedEnvs := make([]string, 0, len(report.RenamedEnvs))
+	for _, rename := range report.RenamedEnvs {
+		renamedEnvs = append(renamedEnvs, fmt.Sprintf("%s->%s", rename.From, rename.To))
+	}
+	add("renamed envs", renamedEnvs)
+	add("new mandatory envs", report.NewMandatoryEnvs)
+	renamed := make([]string, 0, len(report.RenamedVolumes))
+	for _, rename := range report.RenamedVolumes {
+		renamed = append(renamed, fmt.Sprintf("%s->%s", rename.From, rename.To))
+	}
+	add("renamed volumes", renamed)
+	add("added volumes", report.AddedVolumes)
+	add("removed volumes", report.RemovedVolumes)
+	add("added sidecars", report.AddedSidecars)
+	add("removed sidecars", report.RemovedSidecars)
+	add("new mandatory sidecars", report.NewMandatorySidecars)
+	add("new mandatory storage class params", report.NewMandatoryStorageClassParams)
+	add("rbac changes", report.RBACChanges)
+	summary := fmt.Sprintf("Changes from %s to %s", report.FromVersion, report.ToVersion)
+	if report.FromK8sVersion != "" {
+		summary = fmt.Sprintf("%s (driver config of %s for K8s %s)", summary, report.FromVersion, report.FromK8sVersion)
+	}
+	if len(changes) == 0 {
+		return fmt.Sprintf("%s: none", summary)
+	}
+	return fmt.Sprintf("%s: %s", summary, strings.Join(changes, "; "))
+}
@@ -40,6 +40,12 @@ type DriverEnv struct {
 	SetForNode                bool        `json:"SetForNode"`
 	DefaultValueForController string      `json:"DefaultValueForController"`
 	DefaultValueForNode       string      `json:"DefaultValueForNode"`
+	// DeprecatedNames are the previous names of the environment variable
+	DeprecatedNames []string `json:"DeprecatedNames,omitempty"`
+	// Removed is set if the environment variable is no longer supported by the driver
+	Removed bool `json:"Removed,omitempty"`
+	// ReplacedBy is the name of the environment variable which replaces a removed environment variable
+	ReplacedBy string `json:"ReplacedBy,omitempty"`
 }
 
 // DriverConfig - Type representing the default configuration of the driver
@@ -162,8 +168,7 @@ func (c *Config) InitDriverConfig(configDirectory string) error {
 		return err
 	}
 	c.imageMap = imageMap
-	configVersion := strings.Replace(c.ConfigVersion, ".", "", -1)
-	driverVersion := fmt.Sprintf("%s_%s_%s", string(c.DriverType), configVersion, c.KubeAPIVersion)
+	driverVersion := GetDriverConfigFileName(c.DriverType, c.ConfigVersion, c.KubeAPIVersion)
 	c.DriverVersion = driverVersion
 
 	driverConfig, err := readConfig(configDirectory, driverVersion, c.Log)
@@ -175,6 +180,25 @@ func (c *Config) InitDriverConfig(configDirectory string) error {
 	return nil
 }
 
+// GetEnvReplacements - Returns the deprecated environment variable names mapped to the names replacing them
+// Removed environment variables without a replacement are mapped to an empty string
+func (driverConfig *DriverConfig) GetEnvReplacements() map[string]string {
+	replacements := make(map[string]string)
+	for _, env := range driverConfig.DriverEnvs {
+		for _, deprecatedName := range env.DeprecatedNames {
+			replacements[deprecatedName] = env.Name
+		}
+	}
+	for _, env := range driverConfig.DriverEnvs {
+		if env.Removed {
+			if _, ok := replacements[env.Name]; !ok {
+				replacements[env.Name] = env.ReplacedBy
+			}
+		}
+	}
+	return replacements
+}
+
 // IsControllerHAEnabled - Determines whether Controller HA is enabled or not
 func (c *Config) IsControllerHAEnabled(imageName string) bool {
 	return c.DriverConfig.ControllerHA
@@ -195,7 +219,7 @@ func (c *Config) GetControllerEnvs() []corev1.EnvVar {
 		return envs
 	}
 	for _, env := range c.DriverConfig.DriverEnvs {
-		if env.SetForController {
+		if env.SetForController && !env.Removed {
 			if env.CSIEnvType == EnvVarReferenceType {
 				fields := strings.Split(env.DefaultValueForController, "/")
 				if len(fields) != 2 {
@@ -249,7 +273,7 @@ func (c *Config) GetNodeEnvs() []corev1.EnvVar {
 		return envs
 	}
 	for _, env := range c.DriverConfig.DriverEnvs {
-		if env.SetForNode {
+		if env.SetForNode && !env.Removed {
 			if env.CSIEnvType == EnvVarReferenceType {
 				fields := strings.Split(env.DefaultValueForNode, "/")
 				if len(fields) != 2 {
@@ -579,6 +603,9 @@ func (c *Config) GetMandatoryEnvNames(driverContainerType string) []string {
 func (c *Config) ValidateEnvironmentVarType(envFromSpec corev1.EnvVar) (bool, error) {
 	for _, env := range c.DriverConfig.DriverEnvs {
 		if envFromSpec.Name == env.Name {
+			if env.Removed {
+				return false, fmt.Errorf("environment variable %s is no longer supported by the driver", env.Name)
+			}
 			isValid := checkEnvType(env.CSIEnvType, envFromSpec.Value)
 			if isValid {
 				if env.Mandatory && envFromSpec.Value == "" {
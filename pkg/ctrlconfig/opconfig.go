@@ -19,9 +19,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"gopkg.in/yaml.v3"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
 )
 
 // OpConfig - Represents the image & RBAC config used by Operator
@@ -35,13 +37,30 @@ type OpConfig struct {
 // DriverConfigParams - Represents the driver and its config versions
 type DriverConfigParams struct {
 	Name           csiv1.DriverType      `yaml:"name"`
+	UpgradePaths   []UpgradePath         `yaml:"upgradePaths,omitempty"`
+	Channels       []Channel             `yaml:"channels,omitempty"`
 	ConfigVersions []ConfigVersionParams `yaml:"configVersions"`
 }
 
+// Channel - Represents an alias (e.g. latest, stable) which can be used instead of a config version
+// The alias resolves to the first config version in the list which is supported on the K8s version
+type Channel struct {
+	Name           string   `yaml:"name"`
+	ConfigVersions []string `yaml:"configVersions"`
+}
+
+// UpgradePath - Represents the config versions a driver config version can be upgraded to
+type UpgradePath struct {
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+}
+
 // ConfigVersionParams - Represents a specific config version of the driver
 type ConfigVersionParams struct {
 	ConfigVersion     string                   `yaml:"configVersion"`
 	UseDefaults       bool                     `yaml:"useDefaults,omitempty"`
+	Driver            string                   `yaml:"driver,omitempty"`
+	Sdc               string                   `yaml:"sdc,omitempty"`
 	SupportedVersions []SupportedVersionParams `yaml:"supportedVersions"`
 	Attacher          string                   `yaml:"attacher,omitempty"`
 	Provisoner        string                   `yaml:"provisioner,omitempty"`
@@ -154,6 +173,9 @@ func (opConfig *OpConfig) GetDefaultImageTags(driverType csiv1.DriverType, confi
 		if driver.Name == driverType {
 			for _, configVersionParams := range driver.ConfigVersions {
 				if configVersionParams.ConfigVersion == configVersion {
+					if configVersionParams.Driver != "" {
+						imageMap[string(csiv1.ImageTypeDriver)] = configVersionParams.Driver
+					}
 					if configVersionParams.UseDefaults {
 						for _, sideCar := range opConfig.CSISideCars {
 							for _, image := range sideCar.Images {
@@ -206,6 +228,10 @@ func (opConfig *OpConfig) GetDefaultImageTags(driverType csiv1.DriverType, confi
 			}
 		}
 	}
+	// A config version specific SDC image takes precedence over the extension
+	if sdcImage := opConfig.getConfigVersionParams(driverType, configVersion).Sdc; sdcImage != "" {
+		imageMap[csiv1.Sdc] = sdcImage
+	}
 	// Validate if all image tags were populated
 	for k, v := range imageMap {
 		if v == "" {
@@ -214,3 +240,181 @@ func (opConfig *OpConfig) GetDefaultImageTags(driverType csiv1.DriverType, confi
 	}
 	return imageMap, nil
 }
+
+// getConfigVersionParams - Returns the params for a specific driver config version
+func (opConfig *OpConfig) getConfigVersionParams(driverType csiv1.DriverType, configVersion string) ConfigVersionParams {
+	for _, driver := range opConfig.Drivers {
+		if driver.Name == driverType {
+			for _, configVersionParams := range driver.ConfigVersions {
+				if configVersionParams.ConfigVersion == configVersion {
+					return configVersionParams
+				}
+			}
+		}
+	}
+	return ConfigVersionParams{}
+}
+
+// GetAvailableUpgrades - Returns the config versions newer than the given config version
+// which are supported on the given K8s version, sorted from oldest to newest
+// A channel alias is resolved to its config version first
+func (opConfig *OpConfig) GetAvailableUpgrades(driverType csiv1.DriverType, configVersion string,
+	k8sVersion csiv1.K8sVersion) []string {
+	configVersion, err := opConfig.ResolveConfigVersion(driverType, configVersion, k8sVersion)
+	if err != nil {
+		return nil
+	}
+	current, err := utilversion.ParseGeneric(configVersion)
+	if err != nil {
+		return nil
+	}
+	upgrades := make([]*utilversion.Version, 0)
+	upgradeNames := make(map[*utilversion.Version]string)
+	for _, driver := range opConfig.Drivers {
+		if driver.Name != driverType {
+			continue
+		}
+		for _, configVersionParams := range driver.ConfigVersions {
+			candidate, err := utilversion.ParseGeneric(configVersionParams.ConfigVersion)
+			if err != nil || !current.LessThan(candidate) {
+				continue
+			}
+			if opConfig.IsSupportedVersion(driverType, configVersionParams.ConfigVersion, k8sVersion) != nil {
+				continue
+			}
+			upgrades = append(upgrades, candidate)
+			upgradeNames[candidate] = configVersionParams.ConfigVersion
+		}
+	}
+	sort.Slice(upgrades, func(i, j int) bool {
+		return upgrades[i].LessThan(upgrades[j])
+	})
+	var availableUpgrades []string
+	for _, upgrade := range upgrades {
+		availableUpgrades = append(availableUpgrades, upgradeNames[upgrade])
+	}
+	return availableUpgrades
+}
+
+// IsConfigVersionAlias - Returns true if the config version is one of the channels declared for the driver
+func (opConfig *OpConfig) IsConfigVersionAlias(driverType csiv1.DriverType, configVersion string) bool {
+	return opConfig.getChannel(driverType, configVersion) != nil
+}
+
+// ResolveConfigVersion - Returns the config version a channel resolves to on the given K8s version
+// Config versions which are not channels are returned as is
+func (opConfig *OpConfig) ResolveConfigVersion(driverType csiv1.DriverType, configVersion string,
+	k8sVersion csiv1.K8sVersion) (string, error) {
+	channel := opConfig.getChannel(driverType, configVersion)
+	if channel == nil {
+		return configVersion, nil
+	}
+	for _, candidate := range channel.ConfigVersions {
+		if opConfig.IsSupportedVersion(driverType, candidate, k8sVersion) == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no config version of channel %s is supported on K8s version %s", configVersion, k8sVersion)
+}
+
+// ResolveConfigVersionFrom - Returns the first config version of a channel which is supported on the given K8s version
+// & which the driver is allowed to move to from its current config version as per the upgrade paths
+// Config versions which are not channels are returned as is. Without a current config version, the channel is
+// resolved as in ResolveConfigVersion
+func (opConfig *OpConfig) ResolveConfigVersionFrom(driverType csiv1.DriverType, configVersion string,
+	k8sVersion csiv1.K8sVersion, fromVersion string) (string, error) {
+	channel := opConfig.getChannel(driverType, configVersion)
+	if channel == nil || fromVersion == "" {
+		return opConfig.ResolveConfigVersion(driverType, configVersion, k8sVersion)
+	}
+	for _, candidate := range channel.ConfigVersions {
+		if opConfig.IsSupportedVersion(driverType, candidate, k8sVersion) != nil {
+			continue
+		}
+		if opConfig.IsUpgradeAllowed(driverType, fromVersion, candidate) == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no config version of channel %s supported on K8s version %s can be reached from %s "+
+		"through the upgrade paths", configVersion, k8sVersion, fromVersion)
+}
+
+// getChannel - Returns the channel with the given name for a driver
+func (opConfig *OpConfig) getChannel(driverType csiv1.DriverType, name string) *Channel {
+	for _, driver := range opConfig.Drivers {
+		if driver.Name != driverType {
+			continue
+		}
+		for i := range driver.Channels {
+			if driver.Channels[i].Name == name {
+				return &driver.Channels[i]
+			}
+		}
+	}
+	return nil
+}
+
+// GetNextUpgrade - Returns the next config version the driver should be moved to
+// as per the upgrade policy. Returns an empty string if no upgrade is allowed
+func GetNextUpgrade(configVersion string, availableUpgrades []string, policy csiv1.UpgradePolicy) string {
+	current, err := utilversion.ParseGeneric(configVersion)
+	if err != nil {
+		return ""
+	}
+	for _, upgrade := range availableUpgrades {
+		candidate, err := utilversion.ParseGeneric(upgrade)
+		if err != nil || candidate.Major() != current.Major() {
+			continue
+		}
+		switch policy {
+		case csiv1.UpgradePolicyAutoMinor:
+			return upgrade
+		case csiv1.UpgradePolicyAutoPatch:
+			if candidate.Minor() == current.Minor() {
+				return upgrade
+			}
+		}
+	}
+	return ""
+}
+
+// IsUpgradeAllowed - Returns an error if the driver is not allowed to move from one config version to another.
+// Downgrades are never allowed. If no upgrade paths are declared for the driver, all upgrades are allowed
+func (opConfig *OpConfig) IsUpgradeAllowed(driverType csiv1.DriverType, fromVersion, toVersion string) error {
+	if fromVersion == toVersion {
+		return nil
+	}
+	from, err := utilversion.ParseGeneric(fromVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse config version %s: %v", fromVersion, err)
+	}
+	to, err := utilversion.ParseGeneric(toVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse config version %s: %v", toVersion, err)
+	}
+	if to.LessThan(from) {
+		return fmt.Errorf("downgrade of config version from %s to %s is not allowed", fromVersion, toVersion)
+	}
+	for _, driver := range opConfig.Drivers {
+		if driver.Name != driverType {
+			continue
+		}
+		if len(driver.UpgradePaths) == 0 {
+			return nil
+		}
+		allowedVersions := make([]string, 0)
+		for _, upgradePath := range driver.UpgradePaths {
+			if upgradePath.From == fromVersion {
+				allowedVersions = append(allowedVersions, upgradePath.To...)
+			}
+		}
+		for _, allowedVersion := range allowedVersions {
+			if allowedVersion == toVersion {
+				return nil
+			}
+		}
+		return fmt.Errorf("upgrade of config version from %s to %s is not allowed. Allowed upgrades: %v",
+			fromVersion, toVersion, allowedVersions)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetPodTemplateChecksum - Annotates the pod template with a checksum of the pod spec
+// and the content of all the ConfigMaps referenced by it. The restart nonce & the checksum
+// of the referenced Secrets (if set on the pod template) are included as well
+func SetPodTemplateChecksum(ctx context.Context, template *corev1.PodTemplateSpec, namespace string, client client.Client) error {
+	checksum, err := GetPodTemplateChecksum(ctx, template.Spec, namespace, client)
+	if err != nil {
+		return err
+	}
+	annotations := template.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if nonce, ok := annotations[constants.PodTemplateRestartedAtKey]; ok {
+		// A restart of the pods changes the checksum as well
+		checksum = fmt.Sprintf("%x", sha256.Sum256([]byte(checksum+nonce)))
+	}
+	if secretsChecksum, ok := annotations[constants.PodTemplateSecretsChecksumKey]; ok {
+		// So does a rotation of the referenced Secrets
+		checksum = fmt.Sprintf("%x", sha256.Sum256([]byte(checksum+secretsChecksum)))
+	}
+	annotations[constants.PodTemplateChecksumKey] = checksum
+	template.SetAnnotations(annotations)
+	return nil
+}
+
+// SetPodTemplateRestartNonce - Annotates the pod template with the restart nonce (if set)
+func SetPodTemplateRestartNonce(template *corev1.PodTemplateSpec, nonce string) {
+	if nonce == "" {
+		return
+	}
+	annotations := template.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[constants.PodTemplateRestartedAtKey] = nonce
+	template.SetAnnotations(annotations)
+}
+
+// SetPodTemplateSecretsChecksum - Annotates the pod template with a checksum of the content of
+// all the Secrets referenced by it (if any), so that a rotation of the Secrets rolls out the pods
+func SetPodTemplateSecretsChecksum(ctx context.Context, template *corev1.PodTemplateSpec, namespace string,
+	client client.Client) error {
+	checksum, err := GetSecretsChecksum(ctx, template.Spec, namespace, client)
+	if err != nil || checksum == "" {
+		return err
+	}
+	annotations := template.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[constants.PodTemplateSecretsChecksumKey] = checksum
+	template.SetAnnotations(annotations)
+	return nil
+}
+
+// GetPodTemplateChecksum - Returns a checksum of the pod spec and the content of
+// all the ConfigMaps referenced by it
+func GetPodTemplateChecksum(ctx context.Context, podSpec corev1.PodSpec, namespace string, client client.Client) (string, error) {
+	hash := sha256.New()
+	podSpecJSON, err := json.Marshal(podSpec)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(podSpecJSON)
+	configMaps, _ := getReferencedObjects(podSpec)
+	for _, name := range configMaps {
+		configMap := &corev1.ConfigMap{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, configMap)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// Referenced objects may be optional
+				continue
+			}
+			return "", err
+		}
+		dataJSON, err := json.Marshal([]interface{}{configMap.Data, configMap.BinaryData})
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "configmap/%s:", name)
+		hash.Write(dataJSON)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// GetSecretsChecksum - Returns a checksum of the content of all the Secrets referenced by the pod spec
+// Returns an empty string if no Secrets are referenced
+func GetSecretsChecksum(ctx context.Context, podSpec corev1.PodSpec, namespace string, client client.Client) (string, error) {
+	secrets := GetReferencedSecrets(podSpec)
+	if len(secrets) == 0 {
+		return "", nil
+	}
+	hash := sha256.New()
+	for _, name := range secrets {
+		secret := &corev1.Secret{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		dataJSON, err := json.Marshal([]interface{}{secret.Data, secret.StringData})
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "secret/%s:", name)
+		hash.Write(dataJSON)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// GetReferencedSecrets - Returns the sorted names of the Secrets referenced by a pod spec
+func GetReferencedSecrets(podSpec corev1.PodSpec) []string {
+	_, secrets := getReferencedObjects(podSpec)
+	return secrets
+}
+
+// getReferencedObjects - Returns the sorted names of the ConfigMaps & Secrets referenced by a pod spec
+func getReferencedObjects(podSpec corev1.PodSpec) ([]string, []string) {
+	configMaps := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			configMaps[volume.ConfigMap.Name] = true
+		}
+		if volume.Secret != nil {
+			secrets[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					configMaps[source.ConfigMap.Name] = true
+				}
+				if source.Secret != nil {
+					secrets[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMaps[envFrom.ConfigMapRef.Name] = true
+			}
+			if envFrom.SecretRef != nil {
+				secrets[envFrom.SecretRef.Name] = true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				configMaps[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				secrets[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+	}
+	return sortedKeys(configMaps), sortedKeys(secrets)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
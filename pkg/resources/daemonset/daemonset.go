@@ -17,6 +17,11 @@ package daemonset
 import (
 	"context"
 	customError "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/dell/dell-csi-operator/pkg/constants"
@@ -28,6 +33,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -54,6 +61,11 @@ func New(instance csiv1.CSIDriver,
 		return nil, customError.New("invalid DNS Policy provided")
 	}
 
+	updateStrategy, err := GetUpdateStrategy(driver.Node.UpdateStrategy)
+	if err != nil {
+		return nil, err
+	}
+
 	labels := make(map[string]string)
 	labels["app"] = daemonSetName
 	containers := make([]corev1.Container, 0)
@@ -115,12 +127,7 @@ func New(instance csiv1.CSIDriver,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
-			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
-				Type: appsv1.RollingUpdateDaemonSetStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
-					MaxUnavailable: &constants.MaxUnavailableUpdateStrategy,
-				},
-			},
+			UpdateStrategy: updateStrategy,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -158,6 +165,10 @@ func SyncDaemonset(ctx context.Context, daemonset *appsv1.DaemonSet, client clie
 		reqLogger.Info("Unknown error.", "Error", err.Error())
 		return err
 	} else {
+		if !reflect.DeepEqual(found.Spec.UpdateStrategy, daemonset.Spec.UpdateStrategy) {
+			reqLogger.Info("Updating the update strategy of the DaemonSet", "Name:", daemonset.Name,
+				"Type:", daemonset.Spec.UpdateStrategy.Type)
+		}
 		reqLogger.Info("Updating DaemonSet", "Name:", daemonset.Name)
 		err = client.Update(ctx, daemonset)
 		if err != nil {
@@ -167,6 +178,204 @@ func SyncDaemonset(ctx context.Context, daemonset *appsv1.DaemonSet, client clie
 	return nil
 }
 
+// GetCanaryName - Returns the name of the canary daemonset
+func GetCanaryName(daemonSetName string) string {
+	return fmt.Sprintf("%s-canary", daemonSetName)
+}
+
+// SetCanary - Converts a daemonset object to the canary daemonset
+func SetCanary(daemonset *appsv1.DaemonSet, nodeSelector map[string]string) {
+	daemonset.Name = GetCanaryName(daemonset.Name)
+	labels := map[string]string{"app": daemonset.Name}
+	daemonset.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	daemonset.Spec.Template.Labels = labels
+	podNodeSelector := make(map[string]string)
+	for key, value := range daemonset.Spec.Template.Spec.NodeSelector {
+		podNodeSelector[key] = value
+	}
+	for key, value := range nodeSelector {
+		podNodeSelector[key] = value
+	}
+	daemonset.Spec.Template.Spec.NodeSelector = podNodeSelector
+}
+
+// ExcludeNodes - Prevents the daemonset pods from being scheduled on the nodes matching the node selector
+func ExcludeNodes(daemonset *appsv1.DaemonSet, nodeSelector map[string]string) {
+	keys := make([]string, 0, len(nodeSelector))
+	for key := range nodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// Node selector terms are ORed, so a node is excluded only if it matches all the labels
+	terms := make([]corev1.NodeSelectorTerm, 0, len(keys))
+	for _, key := range keys {
+		terms = append(terms, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{
+					Key:      key,
+					Operator: corev1.NodeSelectorOpNotIn,
+					Values:   []string{nodeSelector[key]},
+				},
+			},
+		})
+	}
+	daemonset.Spec.Template.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: terms,
+			},
+		},
+	}
+}
+
+// DeleteDaemonset - Deletes a daemonset if it exists
+func DeleteDaemonset(ctx context.Context, name, namespace string, client client.Client, reqLogger logr.Logger) error {
+	found := &appsv1.DaemonSet{}
+	err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	reqLogger.Info("Deleting DaemonSet", "Namespace", namespace, "Name", name)
+	err = client.Delete(ctx, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GetUpdateStrategy - Returns the daemonset update strategy for the given node update strategy
+// Defaults to RollingUpdate with MaxUnavailable set to 1
+func GetUpdateStrategy(strategy *csiv1.NodeUpdateStrategy) (appsv1.DaemonSetUpdateStrategy, error) {
+	maxUnavailable := constants.MaxUnavailableUpdateStrategy
+	if strategy == nil {
+		return appsv1.DaemonSetUpdateStrategy{
+			Type: appsv1.RollingUpdateDaemonSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+				MaxUnavailable: &maxUnavailable,
+			},
+		}, nil
+	}
+	err := ValidateUpdateStrategy(strategy)
+	if err != nil {
+		return appsv1.DaemonSetUpdateStrategy{}, err
+	}
+	if strategy.Type == appsv1.OnDeleteDaemonSetStrategyType || strategy.Type == csiv1.NodeUpdateStrategyVolumeAware {
+		// Node pods are deleted by the operator for the VolumeAware update strategy
+		return appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}, nil
+	}
+	if strategy.MaxUnavailable != nil {
+		maxUnavailable = *strategy.MaxUnavailable
+	} else if strategy.MaxSurge != nil && getIntOrPercentValue(*strategy.MaxSurge) != 0 {
+		// Only one of MaxUnavailable & MaxSurge can be non-zero
+		maxUnavailable = intstr.FromInt(0)
+	}
+	rollingUpdate := &appsv1.RollingUpdateDaemonSet{
+		MaxUnavailable: &maxUnavailable,
+	}
+	if strategy.MaxSurge != nil {
+		maxSurge := *strategy.MaxSurge
+		rollingUpdate.MaxSurge = &maxSurge
+	}
+	return appsv1.DaemonSetUpdateStrategy{
+		Type:          appsv1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: rollingUpdate,
+	}, nil
+}
+
+// ValidateUpdateStrategy - Validates the node update strategy as per the rules of the DaemonSet API
+func ValidateUpdateStrategy(strategy *csiv1.NodeUpdateStrategy) error {
+	if strategy == nil {
+		return nil
+	}
+	if strategy.Pause != nil && strategy.Type != csiv1.NodeUpdateStrategyVolumeAware {
+		return fmt.Errorf("pause can only be specified with the %s update strategy", csiv1.NodeUpdateStrategyVolumeAware)
+	}
+	switch strategy.Type {
+	case appsv1.OnDeleteDaemonSetStrategyType:
+		if strategy.MaxUnavailable != nil || strategy.MaxSurge != nil {
+			return fmt.Errorf("maxUnavailable and maxSurge can't be specified with the %s update strategy",
+				appsv1.OnDeleteDaemonSetStrategyType)
+		}
+		return nil
+	case csiv1.NodeUpdateStrategyVolumeAware:
+		if strategy.MaxSurge != nil {
+			return fmt.Errorf("maxSurge can't be specified with the %s update strategy", csiv1.NodeUpdateStrategyVolumeAware)
+		}
+		if strategy.Pause != nil && strategy.Pause.Duration < 0 {
+			return fmt.Errorf("pause must be greater than or equal to 0")
+		}
+		if strategy.MaxUnavailable != nil {
+			err := validateIntOrPercent(*strategy.MaxUnavailable, "maxUnavailable")
+			if err != nil {
+				return err
+			}
+			if getIntOrPercentValue(*strategy.MaxUnavailable) == 0 {
+				return fmt.Errorf("maxUnavailable cannot be 0 with the %s update strategy", csiv1.NodeUpdateStrategyVolumeAware)
+			}
+		}
+		return nil
+	case "", appsv1.RollingUpdateDaemonSetStrategyType:
+	default:
+		return fmt.Errorf("invalid update strategy type: %s. Valid values are %s, %s and %s", strategy.Type,
+			appsv1.RollingUpdateDaemonSetStrategyType, appsv1.OnDeleteDaemonSetStrategyType, csiv1.NodeUpdateStrategyVolumeAware)
+	}
+	// maxUnavailable defaults to 1 (0 if maxSurge is non-zero)
+	hasUnavailable, hasSurge := true, false
+	if strategy.MaxUnavailable != nil {
+		err := validateIntOrPercent(*strategy.MaxUnavailable, "maxUnavailable")
+		if err != nil {
+			return err
+		}
+		hasUnavailable = getIntOrPercentValue(*strategy.MaxUnavailable) != 0
+	}
+	if strategy.MaxSurge != nil {
+		err := validateIntOrPercent(*strategy.MaxSurge, "maxSurge")
+		if err != nil {
+			return err
+		}
+		hasSurge = getIntOrPercentValue(*strategy.MaxSurge) != 0
+		if strategy.MaxUnavailable == nil {
+			hasUnavailable = !hasSurge
+		}
+	}
+	if hasUnavailable && hasSurge {
+		return fmt.Errorf("maxSurge may not be set when maxUnavailable is non-zero")
+	}
+	if !hasUnavailable && !hasSurge {
+		return fmt.Errorf("maxUnavailable cannot be 0 when maxSurge is 0")
+	}
+	return nil
+}
+
+func validateIntOrPercent(value intstr.IntOrString, fieldName string) error {
+	switch value.Type {
+	case intstr.Int:
+		if value.IntVal < 0 {
+			return fmt.Errorf("%s must be greater than or equal to 0", fieldName)
+		}
+	case intstr.String:
+		if msgs := validation.IsValidPercent(value.StrVal); len(msgs) != 0 {
+			return fmt.Errorf("%s (%s) must be an integer or a percentage: %s", fieldName, value.StrVal,
+				strings.Join(msgs, ", "))
+		}
+		if getIntOrPercentValue(value) > 100 {
+			return fmt.Errorf("%s must not be greater than 100%%", fieldName)
+		}
+	}
+	return nil
+}
+
+func getIntOrPercentValue(value intstr.IntOrString) int {
+	if value.Type == intstr.String {
+		percent, _ := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+		return percent
+	}
+	return value.IntValue()
+}
+
 func isValidDNSPolicy(str string) bool {
 	allowedDNSPolicies := []string{string(corev1.DNSClusterFirst), string(corev1.DNSClusterFirstWithHostNet),
 		string(corev1.DNSNone), string(corev1.DNSDefault)}
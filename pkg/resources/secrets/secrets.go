@@ -98,7 +98,7 @@ func GetSecret(ctx context.Context, name, namespace string, client crclient.Clie
 	found := &corev1.Secret{}
 	err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
-		return nil, fmt.Errorf("no secrets found or error: %v", err)
+		return nil, fmt.Errorf("no secrets found or error: %w", err)
 	}
 	return found, nil
 }
@@ -16,9 +16,6 @@ package statefulset
 
 import (
 	"context"
-	"fmt"
-	"reflect"
-	"time"
 
 	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
 
@@ -34,9 +31,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// SleepTime - minimum time to sleep before checking the state of controller pod
-var SleepTime = 10 * time.Second
-
 // New - Creates a statefulset element for the given driver and component
 func New(instance csiv1.CSIDriver, driverEnv []corev1.EnvVar, driverVolumeMounts []corev1.VolumeMount, podVolumes []corev1.Volume,
 	args []string, sidecarMap map[csiv1.ImageType]ctrlconfig.SidecarParams, podConstraints csiv1.PodSchedulingConstraints) *appsv1.StatefulSet {
@@ -124,80 +118,35 @@ func DeleteStatefulset(ctx context.Context, statefulset *appsv1.StatefulSet, cli
 }
 
 // SyncStatefulset - Syncs a StatefulSet
+// The pod template is annotated with a checksum of the pod spec & referenced ConfigMaps/Secrets
+// so that any change results in a rolling update of the controller pods
 func SyncStatefulset(ctx context.Context, statefulset *appsv1.StatefulSet, client client.Client, reqLogger logr.Logger) error {
+	err := resources.SetPodTemplateChecksum(ctx, &statefulset.Spec.Template, statefulset.Namespace, client)
+	if err != nil {
+		reqLogger.Error(err, "Failed to calculate the pod template checksum")
+		return err
+	}
 	found := &appsv1.StatefulSet{}
-	err := client.Get(ctx, types.NamespacedName{Name: statefulset.Name, Namespace: statefulset.Namespace}, found)
+	err = client.Get(ctx, types.NamespacedName{Name: statefulset.Name, Namespace: statefulset.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		reqLogger.Info("Creating a new Statefulset", "Namespace", statefulset.Namespace, "Name", statefulset.Name)
 		err = client.Create(ctx, statefulset)
 		if err != nil {
 			return err
 		}
-
-		return nil
 	} else if err != nil {
 		reqLogger.Info("Unknown error.", "Error", err.Error())
 		return err
 	} else {
+		if found.Spec.Template.Annotations[constants.PodTemplateChecksumKey] !=
+			statefulset.Spec.Template.Annotations[constants.PodTemplateChecksumKey] {
+			reqLogger.Info("Controller pod template has changed. Pods will be rolled out", "Name:", statefulset.Name)
+		}
 		reqLogger.Info("Updating StatefulSet", "Name:", statefulset.Name)
 		err = client.Update(ctx, statefulset)
 		if err != nil {
 			return err
 		}
-		if statefulset.Status.ReadyReplicas != statefulset.Status.Replicas {
-			// Check if the pod spec is same as pod spec from stateful spec
-			reqLogger.Info("Waiting 10 seconds before checking the status of controller pods")
-			time.Sleep(SleepTime)
-		}
-		err := client.Get(ctx, types.NamespacedName{Name: statefulset.Name, Namespace: statefulset.Namespace}, found)
-		if err != nil && errors.IsNotFound(err) {
-			reqLogger.Error(err, "Failed to find the statefulset after upgrade. Internal error!")
-			return err
-		}
-		podTemplateSpec := found.Spec.Template.Spec
-		for i := found.Status.Replicas - 1; i >= 0; i-- {
-			controllerPod := &corev1.Pod{}
-			controllerPodName := fmt.Sprintf("%s-%d", statefulset.Name, i)
-			err = client.Get(ctx, types.NamespacedName{Name: controllerPodName, Namespace: statefulset.Namespace}, controllerPod)
-			if err == nil {
-				podSpec := controllerPod.Spec
-				if !comparePodSpec(podTemplateSpec, podSpec, reqLogger) {
-					reqLogger.Info("Deleting the controller pod", controllerPodName)
-					err = client.Delete(ctx, controllerPod)
-					if err != nil {
-						reqLogger.Error(err, "Failed to delete the pod. Continuing")
-					}
-				}
-			} else {
-				reqLogger.Error(err, "Failed to get the controller pod. Continuing")
-			}
-		}
 	}
 	return nil
 }
-
-func comparePodSpec(spec1, spec2 corev1.PodSpec, reqLogger logr.Logger) bool {
-	for _, container1 := range spec1.Containers {
-		for _, container2 := range spec2.Containers {
-			if container1.Name == container2.Name {
-				if !reflect.DeepEqual(container1.Env, container2.Env) {
-					reqLogger.Info("Environments don't match for", container1.Name)
-					return false
-				}
-				reqLogger.Info(fmt.Sprintf("Environment variables match for %s", container1.Name))
-				if container1.Image != container2.Image {
-					reqLogger.Info(fmt.Sprintf("Image (%s, %s) don't match for container %s",
-						string(container1.Image), string(container2.Image), container1.Name))
-					return false
-				}
-				if container1.ImagePullPolicy != container2.ImagePullPolicy {
-					reqLogger.Info(fmt.Sprintf("ImagePullPolicy (%s, %s) don't match for container %s",
-						string(container1.ImagePullPolicy), string(container2.ImagePullPolicy), container1.Name))
-					return false
-				}
-			}
-			break
-		}
-	}
-	return true
-}
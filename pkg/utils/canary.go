@@ -0,0 +1,139 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/resources/daemonset"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// getCanaryDriverConfig - Returns the driver config used by the canary Node plugin
+func getCanaryDriverConfig(canary *csiv1.NodeCanary, r ReconcileCSI, driverConfig *ctrlconfig.Config) (*ctrlconfig.Config, error) {
+	if canary.ConfigVersion == "" || canary.ConfigVersion == driverConfig.ConfigVersion {
+		return driverConfig, nil
+	}
+	canaryConfig := &ctrlconfig.Config{
+		ConfigVersion:  canary.ConfigVersion,
+		KubeAPIVersion: driverConfig.KubeAPIVersion,
+		DriverType:     driverConfig.DriverType,
+		Log:            driverConfig.Log,
+		IsOpenShift:    driverConfig.IsOpenShift,
+		ConfigFileName: driverConfig.ConfigFileName,
+	}
+	err := canaryConfig.InitDriverConfig(r.GetConfig().ConfigDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid canary config version %s: %v", canary.ConfigVersion, err)
+	}
+	return canaryConfig, nil
+}
+
+// validateNodeCanary - Validates the canary specification of the Node plugin
+func validateNodeCanary(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config) error {
+	canary := instance.GetDriver().Node.Canary
+	if canary == nil {
+		return nil
+	}
+	if len(canary.NodeSelector) == 0 {
+		return fmt.Errorf("node selector must be specified for the canary")
+	}
+	if canary.Image == "" && canary.ConfigVersion == "" {
+		return fmt.Errorf("either image or config version must be specified for the canary")
+	}
+	_, err := getCanaryDriverConfig(canary, r, driverConfig)
+	return err
+}
+
+// newCanaryDaemonSet - Returns the canary daemonset for the Node plugin
+// Returns nil if no canary has been requested
+func newCanaryDaemonSet(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config, certVolumes []corev1.Volume,
+	createServiceAccount bool, reqLogger logr.Logger) (*appsv1.DaemonSet, error) {
+	canary := instance.GetDriver().Node.Canary
+	if canary == nil || canary.Promote {
+		return nil, nil
+	}
+	canaryConfig, err := getCanaryDriverConfig(canary, r, driverConfig)
+	if err != nil {
+		return nil, err
+	}
+	canaryInstance, ok := instance.DeepCopyObject().(csiv1.CSIDriver)
+	if !ok {
+		return nil, fmt.Errorf("failed to copy the driver instance")
+	}
+	canaryDriver := canaryInstance.GetDriver()
+	canaryDriver.ConfigVersion = canaryConfig.ConfigVersion
+	canaryDriver.Node.Canary = nil
+	if isVolumeAwareRollout(instance) {
+		// The canary nodes are restarted as a whole
+		canaryDriver.Node.UpdateStrategy = nil
+	}
+	if canary.Image != "" {
+		canaryDriver.Common.Image = canary.Image
+	} else if canaryConfig != driverConfig {
+		image, err := canaryConfig.GetDefaultImageTag(string(csiv1.ImageTypeDriver))
+		if err != nil {
+			return nil, fmt.Errorf("driver image must be specified for the canary config version %s", canary.ConfigVersion)
+		}
+		canaryDriver.Common.Image = image
+	}
+	reqLogger.Info(fmt.Sprintf("Canary Node plugin uses image %s and config version %s",
+		canaryDriver.Common.Image, canaryDriver.ConfigVersion))
+	ds, err := newNodeDaemonSet(canaryInstance, canaryConfig, certVolumes, createServiceAccount, reqLogger)
+	if err != nil {
+		return nil, err
+	}
+	daemonset.SetCanary(ds, canary.NodeSelector)
+	return ds, nil
+}
+
+// applyCanaryPromotion - Folds the canary image & config version into the driver spec if a promotion was requested
+// Returns true if the canary was promoted
+func applyCanaryPromotion(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, reqLogger logr.Logger) (bool, error) {
+	driver := instance.GetDriver()
+	canary := driver.Node.Canary
+	if canary == nil || !canary.Promote {
+		return false, nil
+	}
+	if canary.ConfigVersion != "" {
+		driver.ConfigVersion = canary.ConfigVersion
+	}
+	if canary.Image != "" {
+		driver.Common.Image = canary.Image
+		// Make sure that the promoted image isn't replaced by the default image of the config version
+		annotations := instance.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		_ = updateAnnotations(annotations, "false", string(csiv1.ImageTypeDriver), canary.Image)
+		instance.SetAnnotations(annotations)
+	}
+	driver.Node.Canary = nil
+	message := fmt.Sprintf("Canary promoted (image: %s, config version: %s)", driver.Common.Image, driver.ConfigVersion)
+	reqLogger.Info(message)
+	err := r.GetClient().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update CR instance")
+		return true, err
+	}
+	recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonCanaryPromoted, message)
+	return true, nil
+}
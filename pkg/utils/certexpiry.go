@@ -0,0 +1,238 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// certificateExpiryGauge - Expiry time of the earliest expiring certificate mounted in the pods of a CR
+var certificateExpiryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "dell_csi_operator_certificate_expiry_timestamp_seconds",
+	Help: "Expiry time (in seconds since the epoch) of the earliest expiring certificate mounted in the pods of a CR",
+}, []string{"driver", "namespace", "name"})
+
+func init() {
+	metrics.Registry.MustRegister(certificateExpiryGauge)
+}
+
+// getCertExpiryThresholds - Returns the thresholds sorted from the largest to the smallest
+func getCertExpiryThresholds(thresholds []metav1.Duration) []time.Duration {
+	sorted := make([]time.Duration, 0, len(thresholds))
+	for _, threshold := range thresholds {
+		sorted = append(sorted, threshold.Duration)
+	}
+	if len(sorted) == 0 {
+		sorted = append(sorted, constants.DefaultCertExpiryThresholds...)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return sorted
+}
+
+// ValidateCertExpiryThresholds - Validates the certificate expiry thresholds
+func ValidateCertExpiryThresholds(thresholds []metav1.Duration) error {
+	for _, threshold := range thresholds {
+		if threshold.Duration <= 0 {
+			return fmt.Errorf("invalid certificate expiry threshold %s: must be greater than 0", threshold.Duration)
+		}
+	}
+	return nil
+}
+
+// getEarliestCertificateExpiry - Returns the earliest expiring certificate found in any key of the Secrets
+// Returns nil if none of the Secrets hold a PEM encoded certificate
+func getEarliestCertificateExpiry(ctx context.Context, client crclient.Client, namespace string, secretNames []string,
+	reqLogger logr.Logger) *csiv1.CertificateExpiry {
+	var earliest *csiv1.CertificateExpiry
+	for _, name := range secretNames {
+		secret := &corev1.Secret{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+		if err != nil {
+			if !k8serror.IsNotFound(err) {
+				reqLogger.Error(err, "Failed to query for the secret. Skipping the expiry check of its certificates",
+					"Secret", name)
+			}
+			continue
+		}
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			data := secret.Data[key]
+			for {
+				var block *pem.Block
+				block, data = pem.Decode(data)
+				if block == nil {
+					break
+				}
+				if block.Type != "CERTIFICATE" {
+					continue
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					continue
+				}
+				if earliest == nil || cert.NotAfter.Before(earliest.NotAfter.Time) {
+					earliest = &csiv1.CertificateExpiry{
+						Secret:   name,
+						Key:      key,
+						Subject:  cert.Subject.String(),
+						NotAfter: metav1.NewTime(cert.NotAfter),
+					}
+				}
+			}
+		}
+	}
+	return earliest
+}
+
+// CheckCertificateExpiry - Finds the earliest expiring certificate in the Secrets mounted in the pods of the CR,
+// records the certificate expiry condition & exports the expiry time as a metric. A Warning event is emitted
+// whenever the certificate crosses one of the thresholds. Returns the certificate (if any) along with the time
+// after which the expiry has to be checked again
+func CheckCertificateExpiry(ctx context.Context, client crclient.Client, recorder record.EventRecorder,
+	object crclient.Object, driver string, secretNames []string, thresholds []metav1.Duration,
+	oldExpiry *csiv1.CertificateExpiry, conditions *[]metav1.Condition, reqLogger logr.Logger) (*csiv1.CertificateExpiry, time.Duration) {
+	expiry := getEarliestCertificateExpiry(ctx, client, object.GetNamespace(), secretNames, reqLogger)
+	if expiry == nil {
+		certificateExpiryGauge.DeleteLabelValues(driver, object.GetNamespace(), object.GetName())
+		meta.RemoveStatusCondition(conditions, constants.ConditionCertificateExpiry)
+		return nil, 0
+	}
+	certificateExpiryGauge.WithLabelValues(driver, object.GetNamespace(), object.GetName()).Set(
+		float64(expiry.NotAfter.Unix()))
+
+	remaining := time.Until(expiry.NotAfter.Time)
+	nextCheck := constants.CertExpiryCheckInterval
+	for _, threshold := range getCertExpiryThresholds(thresholds) {
+		if remaining <= threshold {
+			expiry.Threshold = &metav1.Duration{Duration: threshold}
+		} else if remaining-threshold < nextCheck {
+			nextCheck = remaining - threshold
+		}
+	}
+	certificate := fmt.Sprintf("Certificate %s in secret %s (key %s)", expiry.Subject, expiry.Secret, expiry.Key)
+	condition := metav1.Condition{
+		Type:               constants.ConditionCertificateExpiry,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: object.GetGeneration(),
+	}
+	if remaining <= 0 {
+		expiry.Threshold = &metav1.Duration{}
+		condition.Reason = constants.ReasonCertificateExpired
+		condition.Message = fmt.Sprintf("%s expired at %s", certificate, expiry.NotAfter.UTC().Format(time.RFC3339))
+	} else if expiry.Threshold != nil {
+		if remaining < nextCheck {
+			nextCheck = remaining
+		}
+		condition.Reason = constants.ReasonCertificateExpiring
+		condition.Message = fmt.Sprintf("%s expires at %s (within %s)", certificate,
+			expiry.NotAfter.UTC().Format(time.RFC3339), expiry.Threshold.Duration)
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = constants.ReasonCertificatesValid
+		condition.Message = fmt.Sprintf("%s expires at %s", certificate, expiry.NotAfter.UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(conditions, condition)
+
+	if expiry.Threshold != nil && (oldExpiry == nil || oldExpiry.Threshold == nil ||
+		oldExpiry.Threshold.Duration != expiry.Threshold.Duration || !oldExpiry.NotAfter.Equal(&expiry.NotAfter)) {
+		reqLogger.Info(fmt.Sprintf("Warning: %s", condition.Message))
+		if recorder != nil {
+			recorder.Event(object, corev1.EventTypeWarning, condition.Reason, condition.Message)
+		}
+	}
+	return expiry, nextCheck
+}
+
+// getDriverCertSecrets - Returns the names of the Secrets mounted in the certs volume of the driver pods
+// along with the credentials secret of the driver, which may also hold certificates
+func getDriverCertSecrets(ctx context.Context, instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config,
+	client crclient.Client) []string {
+	podSpecs := make([]corev1.PodSpec, 0)
+	name := types.NamespacedName{Name: instance.GetControllerName(), Namespace: instance.GetNamespace()}
+	if driverConfig.DriverConfig == nil || driverConfig.DriverConfig.ControllerHA {
+		controller := &appsv1.Deployment{}
+		if err := client.Get(ctx, name, controller); err == nil {
+			podSpecs = append(podSpecs, controller.Spec.Template.Spec)
+		}
+	} else {
+		controller := &appsv1.StatefulSet{}
+		if err := client.Get(ctx, name, controller); err == nil {
+			podSpecs = append(podSpecs, controller.Spec.Template.Spec)
+		}
+	}
+	node := &appsv1.DaemonSet{}
+	name.Name = instance.GetDaemonSetName()
+	if err := client.Get(ctx, name, node); err == nil {
+		podSpecs = append(podSpecs, node.Spec.Template.Spec)
+	}
+	secretNames := make([]string, 0)
+	for _, podSpec := range podSpecs {
+		for _, volume := range podSpec.Volumes {
+			if volume.Name != certVolumeName {
+				continue
+			}
+			if volume.Secret != nil {
+				secretNames = appendIfMissingString(secretNames, volume.Secret.SecretName)
+			}
+			if volume.Projected != nil {
+				for _, source := range volume.Projected.Sources {
+					if source.Secret != nil {
+						secretNames = appendIfMissingString(secretNames, source.Secret.Name)
+					}
+				}
+			}
+		}
+	}
+	if authSecret := instance.GetDriver().AuthSecret; authSecret != "" {
+		secretNames = appendIfMissingString(secretNames, LocalSecretName(authSecret))
+	}
+	return secretNames
+}
+
+// checkDriverCertificateExpiry - Checks the expiry of the certificates mounted in the driver pods & records it
+// in the new status. Returns the time after which the expiry has to be checked again (0 if there are no certificates)
+func checkDriverCertificateExpiry(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI,
+	driverConfig *ctrlconfig.Config, newStatus *csiv1.DriverStatus, reqLogger logr.Logger) time.Duration {
+	secretNames := getDriverCertSecrets(ctx, instance, driverConfig, r.GetClient())
+	expiry, nextCheck := CheckCertificateExpiry(ctx, r.GetClient(), r.GetEventRecorder(), instance,
+		string(instance.GetDriverType()), secretNames, instance.GetDriver().CertExpiryThresholds,
+		instance.GetDriverStatus().CertificateExpiry, &newStatus.Conditions, reqLogger)
+	newStatus.CertificateExpiry = expiry
+	return nextCheck
+}
@@ -0,0 +1,288 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certVolumeName - Name of the volume in which the certificates of the storage arrays are mounted
+const certVolumeName = "certs"
+
+// skipCertValidationEnvs - Envs used by the drivers to skip the validation of the certificates of the storage arrays
+var skipCertValidationEnvs = map[csiv1.DriverType]string{
+	csiv1.PowerMax: "X_CSI_POWERMAX_SKIP_CERTIFICATE_VALIDATION",
+	csiv1.Isilon:   "X_CSI_ISI_SKIP_CERTIFICATE_VALIDATION",
+}
+
+// hasCertSecrets - Returns true if the Secrets holding the certificates are declared in the driver spec
+func hasCertSecrets(instance csiv1.CSIDriver) bool {
+	driver := instance.GetDriver()
+	return driver.CertSecretSelector != nil || len(driver.CertSecrets) != 0
+}
+
+// certSecret - Secret holding certificates along with the keys of the certificates
+type certSecret struct {
+	name string
+	keys []string
+}
+
+// isPEMCertificate - Returns true if the data holds at least one PEM encoded certificate
+func isPEMCertificate(data []byte) bool {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return false
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err == nil {
+			return true
+		}
+	}
+}
+
+// getCertKeys - Returns the sorted keys of the Secret which hold a PEM encoded certificate
+func getCertKeys(secret *corev1.Secret) []string {
+	keys := make([]string, 0)
+	for key, data := range secret.Data {
+		if isPEMCertificate(data) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getDeclaredCertSecrets - Returns the Secrets selected by the certSecretSelector & listed in certSecrets
+// sorted by name. Returns an error listing every listed Secret which doesn't exist
+func getDeclaredCertSecrets(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client) ([]*corev1.Secret, error) {
+	driver := instance.GetDriver()
+	found := make(map[string]*corev1.Secret)
+	if driver.CertSecretSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(driver.CertSecretSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certSecretSelector: %v", err)
+		}
+		list := &corev1.SecretList{}
+		err = client.List(ctx, list, crclient.InNamespace(instance.GetNamespace()),
+			crclient.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			found[list.Items[i].Name] = &list.Items[i]
+		}
+	}
+	missing := &missingDependencies{}
+	for _, ref := range driver.CertSecrets {
+		name := LocalSecretName(ref)
+		if _, ok := found[name]; ok {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.GetNamespace()}, secret)
+		if k8serror.IsNotFound(err) {
+			missing.add(NewMissingSecretError(name, fmt.Errorf("failed to find certificate secret %s", name)))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		found[name] = secret
+	}
+	if err := missing.join(nil); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	secrets := make([]*corev1.Secret, 0, len(names))
+	for _, name := range names {
+		secrets = append(secrets, found[name])
+	}
+	return secrets, nil
+}
+
+// getCertSecrets - Returns the keys holding the certificates for each of the Secrets
+// Returns an error if a Secret doesn't hold a PEM encoded certificate
+func getCertSecrets(secrets []*corev1.Secret) ([]certSecret, error) {
+	certSecrets := make([]certSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		keys := getCertKeys(secret)
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("certificate secret %s doesn't hold a PEM encoded certificate", secret.Name)
+		}
+		certSecrets = append(certSecrets, certSecret{name: secret.Name, keys: keys})
+	}
+	return certSecrets, nil
+}
+
+// getNumberedCertSecrets - Returns the <driver>-certs-<n> Secrets ordered by their number
+// Gaps in the numbers are tolerated. Only the cert-<n> key of each Secret is used
+func getNumberedCertSecrets(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client,
+	reqLogger logr.Logger) ([]certSecret, error) {
+	list := &corev1.SecretList{}
+	err := client.List(ctx, list, crclient.InNamespace(instance.GetNamespace()))
+	if err != nil {
+		return nil, err
+	}
+	nameRegex := regexp.MustCompile(fmt.Sprintf("^%s-certs-([0-9]+)$", instance.GetDriverType()))
+	numbers := make(map[int]certSecret)
+	for _, secret := range list.Items {
+		match := nameRegex.FindStringSubmatch(secret.Name)
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		certName := fmt.Sprintf("cert-%d", number)
+		if _, ok := secret.Data[certName]; !ok {
+			reqLogger.Error(fmt.Errorf("cert Secret [%s] dosen't have key [%s] in the data filed", secret.Name, certName), "")
+			continue
+		}
+		numbers[number] = certSecret{name: secret.Name, keys: []string{certName}}
+	}
+	sortedNumbers := make([]int, 0, len(numbers))
+	for number := range numbers {
+		sortedNumbers = append(sortedNumbers, number)
+	}
+	sort.Ints(sortedNumbers)
+	certSecrets := make([]certSecret, 0, len(sortedNumbers))
+	for _, number := range sortedNumbers {
+		certSecrets = append(certSecrets, numbers[number])
+	}
+	return certSecrets, nil
+}
+
+// getMultipleCertSecretVolume - Returns the projected volume with the certificates of the storage arrays
+// The Secrets declared in the driver spec are used if present. Otherwise the <driver>-certs-<n> Secrets are
+// used for Unity & PowerScale. The Secret converted from the trust bundle (if any) is added after them.
+// Returns an empty volume if there are no certificate Secrets for the driver
+func getMultipleCertSecretVolume(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client,
+	trustBundle *certSecret, reqLogger logr.Logger) (corev1.Volume, error) {
+	var certSecrets []certSecret
+	if hasCertSecrets(instance) {
+		secrets, err := getDeclaredCertSecrets(ctx, instance, client)
+		if err != nil {
+			return corev1.Volume{}, err
+		}
+		certSecrets, err = getCertSecrets(secrets)
+		if err != nil {
+			return corev1.Volume{}, err
+		}
+		if len(certSecrets) == 0 {
+			reqLogger.Info("Warning: No certificate secrets match the certSecretSelector")
+		}
+	} else if instance.GetDriverType() == csiv1.Unity || instance.GetDriverType() == csiv1.Isilon {
+		var err error
+		certSecrets, err = getNumberedCertSecrets(ctx, instance, client, reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "Failed to list the certificate secrets")
+			certSecrets = nil
+		}
+	}
+	if trustBundle != nil {
+		certSecrets = append(certSecrets, *trustBundle)
+	}
+	if len(certSecrets) == 0 && !hasCertSecrets(instance) {
+		// Keep the certs volume from the driver config
+		return corev1.Volume{}, nil
+	}
+	volSources := make([]corev1.VolumeProjection, 0)
+	index := 0
+	for _, secret := range certSecrets {
+		items := make([]corev1.KeyToPath, 0, len(secret.keys))
+		for _, key := range secret.keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: fmt.Sprintf("cert-%d", index)})
+			index++
+		}
+		volSources = append(volSources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret.name},
+				Items:                items,
+			},
+		})
+	}
+	return corev1.Volume{
+		Name: certVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: volSources,
+			},
+		},
+	}, nil
+}
+
+// setCertValidationEnvs - Enables the validation of the certificates of the storage arrays if the certificate
+// Secrets or a trust bundle are declared in the driver spec, unless the user explicitly set the env in the driver spec
+func setCertValidationEnvs(instance csiv1.CSIDriver, envs []corev1.EnvVar, userEnvs ...[]corev1.EnvVar) []corev1.EnvVar {
+	envName, ok := skipCertValidationEnvs[instance.GetDriverType()]
+	if !ok || (!hasCertSecrets(instance) && instance.GetDriver().TrustBundle == nil) {
+		return envs
+	}
+	for _, list := range userEnvs {
+		if present, _ := isEnvPresent(envName, list); present {
+			return envs
+		}
+	}
+	for i := range envs {
+		if envs[i].Name == envName {
+			envs[i].Value = "false"
+		}
+	}
+	return envs
+}
+
+// validateCertSecrets - Validates the certificate Secrets declared in the driver spec
+func validateCertSecrets(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client, log logr.Logger) error {
+	if !hasCertSecrets(instance) {
+		return nil
+	}
+	if selector := instance.GetDriver().CertSecretSelector; selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return fmt.Errorf("invalid certSecretSelector: %v", err)
+		}
+	}
+	secrets, err := getDeclaredCertSecrets(ctx, instance, client)
+	if err != nil {
+		if getMissingDependencies(err) != nil {
+			return err
+		}
+		log.Error(err, "Failed to query for the certificate secrets. Warning - the driver pods may not start")
+		return nil
+	}
+	_, err = getCertSecrets(secrets)
+	return err
+}
@@ -0,0 +1,89 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// resolveConfigVersionAlias - Resolves the channel alias (if any) used as the config version in the driver spec
+// to the config version it points to on this K8s version. The alias is kept in the driver spec & the resolved
+// config version is recorded in the driver status. The alias is resolved on every reconcile so that the driver
+// follows the channel after an operator upgrade, but only through the upgrade paths from the config version
+// recorded in the annotations. If the channel can't be reached, the driver stays on its config version
+func resolveConfigVersionAlias(instance csiv1.CSIDriver, r ReconcileCSI, opConfig *ctrlconfig.OpConfig,
+	reqLogger logr.Logger) error {
+	alias := instance.GetDriver().ConfigVersion
+	status := instance.GetDriverStatus()
+	if !opConfig.IsConfigVersionAlias(instance.GetDriverType(), alias) {
+		status.ResolvedConfigVersion = ""
+		meta.RemoveStatusCondition(&status.Conditions, constants.ConditionChannelBlocked)
+		return nil
+	}
+	k8sVersion := r.GetConfig().KubeAPIServerVersion
+	previousVersion := instance.GetAnnotations()[configVersionKey]
+	if instance.GetAnnotations()[upgradePathOverrideKey] == "true" {
+		// The upgrade paths are skipped once on request
+		previousVersion = ""
+	}
+	configVersion, err := opConfig.ResolveConfigVersionFrom(instance.GetDriverType(), alias, k8sVersion, previousVersion)
+	if err != nil && previousVersion != "" &&
+		opConfig.IsSupportedVersion(instance.GetDriverType(), previousVersion, k8sVersion) == nil {
+		message := fmt.Sprintf("Channel %s is blocked: %s. Staying on config version %s", alias, err.Error(),
+			previousVersion)
+		reqLogger.Info(fmt.Sprintf("Warning: %s", message))
+		if !meta.IsStatusConditionTrue(status.Conditions, constants.ConditionChannelBlocked) {
+			recordEvent(r, instance, corev1.EventTypeWarning, constants.ReasonChannelBlocked, message)
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               constants.ConditionChannelBlocked,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.GetGeneration(),
+			Reason:             constants.ReasonChannelBlocked,
+			Message:            message,
+		})
+		status.ResolvedConfigVersion = previousVersion
+		return nil
+	}
+	meta.RemoveStatusCondition(&status.Conditions, constants.ConditionChannelBlocked)
+	if err != nil {
+		status.ResolvedConfigVersion = ""
+		return err
+	}
+	message := fmt.Sprintf("Config version alias %s resolved to %s", alias, configVersion)
+	reqLogger.Info(message)
+	if status.ResolvedConfigVersion != configVersion {
+		recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonConfigVersionResolved, message)
+	}
+	status.ResolvedConfigVersion = configVersion
+	return nil
+}
+
+// GetConfigVersion - Returns the config version of the driver
+// If a channel alias is used as the config version in the driver spec, the config version it resolved to is returned
+func GetConfigVersion(instance csiv1.CSIDriver) string {
+	if resolvedConfigVersion := instance.GetDriverStatus().ResolvedConfigVersion; resolvedConfigVersion != "" {
+		return resolvedConfigVersion
+	}
+	return instance.GetDriver().ConfigVersion
+}
@@ -0,0 +1,167 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// chapSecretKey - Key of the CHAP secret in the Secret referenced by the PowerMax Node plugin
+	chapSecretKey = "chapsecret"
+	// chapSecretLength - Length of the generated CHAP secret. PowerMax accepts 12 to 16 characters
+	chapSecretLength = 16
+	// chapSecretCharacters - Characters of the generated CHAP secret
+	chapSecretCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// GetCHAPSecretName - Returns the name of the Secret in which the CHAP secret of the driver is generated
+func GetCHAPSecretName(instance csiv1.CSIDriver) string {
+	return fmt.Sprintf("%s-chap", instance.GetName())
+}
+
+// isCHAPAutoGenerationRequested - Returns true if the driver spec enables the CHAP auto generation
+func isCHAPAutoGenerationRequested(instance csiv1.CSIDriver) bool {
+	chap := instance.GetDriver().CHAP
+	return chap != nil && chap.AutoGenerate
+}
+
+// isCHAPSecretGenerated - Returns true if the operator generates the CHAP secret of the driver
+func isCHAPSecretGenerated(instance csiv1.CSIDriver) bool {
+	return isCHAPAutoGenerationRequested(instance) && instance.GetDriverType() == csiv1.PowerMax
+}
+
+// validateCHAP - Validates the CHAP settings in the driver spec
+func validateCHAP(instance csiv1.CSIDriver) error {
+	if !isCHAPAutoGenerationRequested(instance) {
+		return nil
+	}
+	switch driverType := instance.GetDriverType(); driverType {
+	case csiv1.PowerMax:
+		return nil
+	case csiv1.PowerStore:
+		// The PowerStore driver generates the CHAP credentials itself
+		return fmt.Errorf("chap.autoGenerate isn't supported for the %s driver: set X_CSI_POWERSTORE_ENABLE_CHAP "+
+			"to true instead", driverType)
+	default:
+		return fmt.Errorf("chap.autoGenerate isn't supported for the %s driver", driverType)
+	}
+}
+
+// setCHAPEnvs - Enables CHAP in the environment variables of the Node plugin if the CHAP secret is generated
+func setCHAPEnvs(instance csiv1.CSIDriver, envs []corev1.EnvVar) []corev1.EnvVar {
+	if !isCHAPSecretGenerated(instance) {
+		return envs
+	}
+	envs = appendIfMissingEnvVar(envs, corev1.EnvVar{Name: "X_CSI_POWERMAX_ISCSI_ENABLE_CHAP", Value: "true"})
+	return appendIfMissingEnvVar(envs, corev1.EnvVar{
+		Name: "X_CSI_POWERMAX_ISCSI_CHAP_PASSWORD",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: GetCHAPSecretName(instance)},
+				Key:                  chapSecretKey,
+			},
+		},
+	})
+}
+
+// generateCHAPSecret - Returns a random CHAP secret
+func generateCHAPSecret() ([]byte, error) {
+	secret := make([]byte, chapSecretLength)
+	max := big.NewInt(int64(len(chapSecretCharacters)))
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return nil, err
+		}
+		secret[i] = chapSecretCharacters[n.Int64()]
+	}
+	return secret, nil
+}
+
+// getCHAPRotation - Returns the value of the rotate annotation of the CR
+func getCHAPRotation(instance csiv1.CSIDriver) string {
+	return instance.GetAnnotations()[constants.CHAPRotateAnnotation]
+}
+
+// isCHAPRotationDue - Returns true if the CHAP secret has to be generated or rotated
+func isCHAPRotationDue(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client) bool {
+	if !isCHAPSecretGenerated(instance) {
+		return false
+	}
+	found := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: GetCHAPSecretName(instance), Namespace: instance.GetNamespace()}, found)
+	if err != nil {
+		return k8serror.IsNotFound(err)
+	}
+	return found.Annotations[constants.CHAPRotatedAnnotation] != getCHAPRotation(instance)
+}
+
+// syncCHAPSecret - Generates the CHAP secret of the driver into a Secret owned by the driver & rotates it whenever
+// the rotate annotation of the CR changes
+func syncCHAPSecret(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, reqLogger logr.Logger) error {
+	if !isCHAPSecretGenerated(instance) {
+		return nil
+	}
+	client := r.GetClient()
+	rotation := getCHAPRotation(instance)
+	found := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: GetCHAPSecretName(instance), Namespace: instance.GetNamespace()}, found)
+	create := k8serror.IsNotFound(err)
+	if err == nil {
+		if !metav1.IsControlledBy(found, instance) {
+			return fmt.Errorf("secret %s already exists & isn't managed by the driver", found.Name)
+		}
+		if _, ok := found.Data[chapSecretKey]; ok && found.Annotations[constants.CHAPRotatedAnnotation] == rotation {
+			return nil
+		}
+	} else if !create {
+		return err
+	}
+	chapSecret, err := generateCHAPSecret()
+	if err != nil {
+		return err
+	}
+	secret := secrets.New(instance, GetCHAPSecretName(instance))
+	secret.Annotations = map[string]string{constants.CHAPRotatedAnnotation: rotation}
+	secret.Data[chapSecretKey] = chapSecret
+	if create {
+		reqLogger.Info("Creating the CHAP secret", "Name", secret.Name)
+		return client.Create(ctx, secret)
+	}
+	found.Annotations = secret.Annotations
+	found.Data = secret.Data
+	err = client.Update(ctx, found)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("CHAP secret %s rotated", found.Name)
+	reqLogger.Info(message)
+	recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonCHAPSecretRotated, message)
+	return nil
+}
@@ -36,15 +36,17 @@ import (
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources"
 	"github.com/dell/dell-csi-operator/pkg/resources/csidriver"
 	"github.com/dell/dell-csi-operator/pkg/resources/daemonset"
 	"github.com/dell/dell-csi-operator/pkg/resources/rbac"
-	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
 	"github.com/dell/dell-csi-operator/pkg/resources/serviceaccount"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -55,13 +57,14 @@ type ReconcileCSI interface {
 	GetClient() crclient.Client
 	GetScheme() *runtime.Scheme
 	GetConfig() config.Config
+	GetEventRecorder() record.EventRecorder
 	SetClient(crclient.Client)
 	SetScheme(*runtime.Scheme)
 	SetConfig(config.Config)
 	GetUpdateCount() int32
 	IncrUpdateCount()
 	InitializeDriverSpec(instance csiv1.CSIDriver, reqLogger logr.Logger) (bool, error)
-	ValidateDriverSpec(ctx context.Context, instance csiv1.CSIDriver, reqLogger logr.Logger) error
+	ValidateDriverSpec(ctx context.Context, instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config, reqLogger logr.Logger) error
 }
 
 // MetadataPrefix - prefix for all labels & annotations
@@ -69,6 +72,9 @@ const MetadataPrefix = "storage.dell.com"
 
 var configVersionKey = fmt.Sprintf("%s/%s", MetadataPrefix, "CSIDriverConfigVersion")
 
+// upgradePathOverrideKey - annotation which allows config version changes not declared in the upgrade paths
+var upgradePathOverrideKey = fmt.Sprintf("%s/%s", MetadataPrefix, "override-upgrade-path")
+
 func checkAndApplyConfigVersionAnnotations(instance csiv1.CSIDriver, log logr.Logger, update bool) (bool, error) {
 	driver := instance.GetDriver()
 	if driver.ConfigVersion == "" {
@@ -76,6 +82,8 @@ func checkAndApplyConfigVersionAnnotations(instance csiv1.CSIDriver, log logr.Lo
 		return false, fmt.Errorf("mandatory argument: ConfigVersion missing")
 	}
 	// If driver has not been initialized yet, we first annotate the driver with the config version annotation
+	// The config version resolved from a channel alias (if any) is recorded in the annotation
+	currentVersion := GetConfigVersion(instance)
 	if instance.GetDriverStatus().DriverHash == 0 || update {
 		annotations := instance.GetAnnotations()
 		isUpdated := false
@@ -84,18 +92,18 @@ func checkAndApplyConfigVersionAnnotations(instance csiv1.CSIDriver, log logr.Lo
 			isUpdated = true
 		}
 		if configVersion, ok := annotations[configVersionKey]; !ok {
-			annotations[configVersionKey] = driver.ConfigVersion
+			annotations[configVersionKey] = currentVersion
 			isUpdated = true
 			instance.SetAnnotations(annotations)
 			log.Info(fmt.Sprintf("Installing CSI Driver %s with config Version %s. Updating Annotations with Config Version",
-				instance.GetName(), driver.ConfigVersion))
+				instance.GetName(), currentVersion))
 		} else {
-			if configVersion != driver.ConfigVersion {
-				annotations[configVersionKey] = driver.ConfigVersion
+			if configVersion != currentVersion {
+				annotations[configVersionKey] = currentVersion
 				isUpdated = true
 				instance.SetAnnotations(annotations)
 				log.Info(fmt.Sprintf("Config Version changed from %s to %s. Updating Annotations",
-					configVersion, driver.ConfigVersion))
+					configVersion, currentVersion))
 			}
 		}
 		return isUpdated, nil
@@ -117,7 +125,7 @@ func deleteDummyClusterRoleAndRemoveFinalizer(ctx context.Context, instance csiv
 		// Not found
 		// We have a finalizer set but no dummy clusterrole
 		// Try to sync the driver again to update any obsolete ownerreferences
-		configVersion := instance.GetDriver().ConfigVersion
+		configVersion := GetConfigVersion(instance)
 		configDirectory := r.GetConfig().ConfigDirectory
 		driverConfig := &ctrlconfig.Config{
 			ConfigVersion:  configVersion,
@@ -184,7 +192,6 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 	reqLogger = reqLogger.WithValues("Attempt", r.GetUpdateCount())
 	reqLogger.Info(fmt.Sprintf("Reconciling %s ", driverType), "request", request.String())
 
-	retryInterval := constants.DefaultRetryInterval
 	// atomic.AddInt32(&r.updateCount, 1)
 	reqLogger.Info("################Starting Reconcile##############")
 	r.IncrUpdateCount()
@@ -201,6 +208,8 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	retry := getRetryPolicy(instance, r)
+	retryInterval := retry.interval
 	isCustomResourceMarkedForDeletion := instance.GetDeletionTimestamp() != nil
 	if isCustomResourceMarkedForDeletion {
 		return deleteDummyClusterRoleAndRemoveFinalizer(ctx, instance, r, reqLogger)
@@ -214,7 +223,36 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		return reconcile.Result{}, err
 	}
 
-	configVersion := instance.GetDriver().ConfigVersion
+	// Restore the driver spec from the revision history if a rollback was requested
+	rollback, err := applyRollback(ctx, instance, r, reqLogger)
+	if rollback {
+		return logBannerAndReturn(reconcile.Result{Requeue: true}, err, reqLogger)
+	}
+
+	// Promote the canary of the Node plugin if requested
+	promoted, err := applyCanaryPromotion(ctx, instance, r, reqLogger)
+	if promoted {
+		return logBannerAndReturn(reconcile.Result{Requeue: true}, err, reqLogger)
+	}
+
+	// The config version resolved from the channel alias (if any) which was last recorded in the status
+	resolvedConfigVersion := instance.GetDriverStatus().ResolvedConfigVersion
+	// Resolve the channel alias (if any) used as the config version & apply the upgrade policy
+	// before the driver config is read
+	var availableUpgrades []string
+	opConfig, upgradeErr := ctrlconfig.ReadOpConfig(r.GetConfig().ConfigDirectory, r.GetConfig().ConfigFile)
+	if upgradeErr == nil {
+		upgradeErr = resolveConfigVersionAlias(instance, r, opConfig, reqLogger)
+	}
+	if upgradeErr == nil {
+		var upgraded bool
+		availableUpgrades, upgraded, upgradeErr = applyUpgradePolicy(ctx, instance, r, opConfig, reqLogger)
+		if upgraded {
+			return logBannerAndReturn(reconcile.Result{Requeue: true}, nil, reqLogger)
+		}
+	}
+
+	configVersion := GetConfigVersion(instance)
 	configDirectory := r.GetConfig().ConfigDirectory
 	driverConfig := &ctrlconfig.Config{
 		ConfigVersion:  configVersion,
@@ -224,6 +262,9 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		IsOpenShift:    r.GetConfig().IsOpenShift,
 		ConfigFileName: r.GetConfig().ConfigFile,
 	}
+	if upgradeErr != nil {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, upgradeErr)
+	}
 
 	err = driverConfig.InitDriverConfig(configDirectory)
 	if err != nil {
@@ -231,6 +272,22 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
 	}
 
+	// Check if the config version change (if any) is allowed
+	err = validateConfigVersionChange(instance, opConfig, reqLogger)
+	if err != nil {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
+	}
+	// Rename the deprecated environment variables in the driver spec
+	err = migrateDeprecatedEnvs(ctx, instance, r, driverConfig, reqLogger)
+	if err != nil {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
+	}
+	// Report the changes in the new config version (if any)
+	err = reportUpgradeCompatibility(ctx, instance, r, driverConfig, reqLogger)
+	if err != nil {
+		return logBannerAndReturn(reconcile.Result{Requeue: true}, err, reqLogger)
+	}
+
 	// Before doing anything else, check for config version and apply annotation if not set
 	isUpdated, err := checkAndApplyConfigVersionAnnotations(instance, log, false)
 	if err != nil {
@@ -247,9 +304,15 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 	// oldStatus is the previous status of the CR instance
 	// This is used to compare if there is a need to update the status
 	oldStatus := status.DeepCopy()
+	oldStatus.ResolvedConfigVersion = resolvedConfigVersion
 	oldState := oldStatus.State
 	reqLogger.Info(fmt.Sprintf("Driver was previously in (%s) state", string(oldState)))
+	newStatus.AvailableUpgrades = availableUpgrades
+	// Missing dependencies are recorded again if the validation fails
+	newStatus.MissingDependencies = nil
 
+	// ForceUpdate is a deprecated alias of RestartNonce
+	forceUpdate := applyForceUpdate(instance, reqLogger)
 	// Check if the driver has changed
 	expectedHash, actualHash, changed := driverChanged(instance)
 	if changed {
@@ -259,8 +322,20 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 	} else {
 		reqLogger.Info("No changes detected in the driver spec")
 	}
-	// Check if force update was requested
-	forceUpdate := instance.GetDriver().ForceUpdate
+	// Hold back changes to a deployed driver till the next maintenance window
+	newStatus.PendingUpdate = nil
+	deferredFor := time.Duration(0)
+	if changed && actualHash != 0 && (oldState == constants.Running || oldState == constants.Succeeded ||
+		oldState == constants.Updating) {
+		deferredFor, err = deferToMaintenanceWindow(instance, r, expectedHash, newStatus, oldStatus, reqLogger)
+		if err != nil {
+			return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
+		}
+		if deferredFor != 0 {
+			changed = false
+			newStatus.DriverHash = actualHash
+		}
+	}
 	checkStateOnly := false
 	switch oldState {
 	case constants.Running:
@@ -286,6 +361,10 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 			if changed {
 				// Do a reconcile as we detected a change
 				newStatus.State = constants.Updating
+			} else if len(oldStatus.MissingDependencies) != 0 {
+				// Retry as one of the missing dependencies may have been created
+				reqLogger.Info("Retrying as the driver was waiting for missing dependencies")
+				newStatus.State = constants.Updating
 			} else {
 				reqLogger.Info(fmt.Sprintf("CR is in (%s) state. Reconcile request won't be requeued",
 					newStatus.State))
@@ -297,6 +376,22 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 	case constants.Updating:
 		reqLogger.Info("Driver already in Updating state")
 	}
+	if checkStateOnly && isCHAPRotationDue(ctx, instance, r.GetClient()) {
+		// The CHAP secret has to be generated again & rolled out to the Node plugin
+		reqLogger.Info("Changed state to Updating as a rotation of the CHAP secret was requested")
+		newStatus.State = constants.Updating
+		checkStateOnly = false
+	}
+	if checkStateOnly && isSecretRolloutDue(ctx, instance, driverConfig, r.GetClient()) {
+		// The driver pods have to be rolled out with the rotated Secrets
+		reqLogger.Info("Changed state to Updating as the content of the referenced Secrets changed")
+		newStatus.State = constants.Updating
+		checkStateOnly = false
+	}
+	if deferredFor != 0 {
+		// Don't sync the deferred changes to the driver
+		checkStateOnly = true
+	}
 
 	// Always initialize the spec
 	isUpdated, err = InitializeSpec(instance, r, driverConfig, reqLogger)
@@ -307,13 +402,23 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 
 	// Check if driver is in running state (only if the status was previously set to Succeeded or Running)
 	if checkStateOnly {
-		return handleSuccess(ctx, instance, driverConfig, r, reqLogger, newStatus, oldStatus)
+		if deferredFor != 0 && forceUpdate {
+			// Persist the restart nonce which replaced the force update field, so that the same restart
+			// is applied in the next maintenance window
+			if err := updateInstance(ctx, instance, r, reqLogger, true); err != nil {
+				return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, err, reqLogger)
+			}
+		}
+		result, err := handleSuccess(ctx, instance, driverConfig, r, reqLogger, newStatus, oldStatus)
+		if deferredFor != 0 && err == nil && (!result.Requeue || result.RequeueAfter > deferredFor) {
+			// Reconcile again at the start of the next maintenance window
+			result.Requeue = true
+			result.RequeueAfter = deferredFor
+		}
+		return result, err
 	}
-	// Remove the force update field if set
-	// The assumption is that we will not have a spec with Running/Succeeded state
-	// and the forceUpdate field set
+	// Persist the removal of the force update field
 	if forceUpdate {
-		instance.GetDriver().ForceUpdate = false
 		isUpdated = true
 	}
 	if changed {
@@ -336,13 +441,43 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, updateStatusError, reqLogger)
 		}
 	}
+	// Create the ConfigMap in which OpenShift injects its trusted CA bundle before the spec is validated
+	owner := GetMirrorOwnerReference(resources.GetOwnerReferences(instance))
+	err = SyncTrustedCABundleConfigMap(ctx, instance.GetDriver().TrustBundle, owner, instance.GetNamespace(),
+		r.GetClient(), reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failed to create the ConfigMap for the trusted CA bundle")
+		return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, err, reqLogger)
+	}
+	// Copy the secrets referenced from other namespaces before the spec is validated
+	err = ValidateSecretRefs(GetDriverSecretRefs(instance), instance.GetNamespace(), r.GetConfig().SecretSourceNamespaces)
+	if err == nil {
+		err = mirrorDriverSecrets(ctx, instance, r, reqLogger)
+		if err != nil && !IsMissingDependency(err) {
+			reqLogger.Error(err, "Failed to mirror the secrets referenced from other namespaces")
+			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, err, reqLogger)
+		}
+	}
+	if err != nil {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
+	}
 	// Validate Spec
+	// The driver specific things are still validated if only dependencies are missing so that all of them are reported
+	missing := &missingDependencies{}
 	err = ValidateSpec(ctx, instance, r, driverConfig, reqLogger)
-	if err != nil {
+	if err != nil && !missing.add(err) {
 		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
 	}
 	// Validate any driver specific things
-	err = r.ValidateDriverSpec(ctx, instance, reqLogger)
+	err = r.ValidateDriverSpec(ctx, instance, driverConfig, reqLogger)
+	if err != nil && !missing.add(err) {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, missing.join(err))
+	}
+	if err = missing.join(nil); err != nil {
+		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
+	}
+	// Validate the storage arrays configured for the driver & report them in the status
+	err = validateStorageArrays(ctx, instance, r, newStatus, reqLogger)
 	if err != nil {
 		return handleValidationError(ctx, instance, driverConfig, r, reqLogger, err)
 	}
@@ -353,14 +488,23 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 	if syncErr == nil {
 		// Mark the driver state as succeeded
 		newStatus.State = constants.Succeeded
+		newStatus.RestartNonce = instance.GetDriver().RestartNonce
 		errorMsg := ""
 		running, err := calculateState(ctx, instance, driverConfig, r, newStatus)
 		if err != nil {
 			errorMsg = err.Error()
 		}
+		rolloutInterval := progressNodeRollout(ctx, instance, r, driverConfig, newStatus, reqLogger)
+		if rolloutInterval != 0 {
+			// Node pods are still being restarted
+			running = false
+			retryInterval = rolloutInterval
+		}
 		if running {
 			newStatus.State = constants.Running
+			recordDriverRevision(ctx, instance, r, driverConfig, newStatus, reqLogger)
 		}
+		certCheckInterval := checkDriverCertificateExpiry(ctx, instance, r, driverConfig, newStatus, reqLogger)
 		newStatus.LastUpdate = setLastStatusUpdate(oldStatus,
 			GetOperatorConditionTypeFromState(newStatus.State), errorMsg)
 		updateStatusError := updateStatus(ctx, instance, r, reqLogger, newStatus, oldStatus)
@@ -370,6 +514,10 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		if newStatus.State != constants.Running {
 			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: retryInterval}, nil, reqLogger)
 		}
+		if certCheckInterval != 0 {
+			// Check the expiry of the certificates again
+			return logBannerAndReturn(reconcile.Result{Requeue: true, RequeueAfter: certCheckInterval}, nil, reqLogger)
+		}
 		return logBannerAndReturn(reconcile.Result{}, nil, reqLogger)
 	}
 	// Failed to sync driver deployment
@@ -381,7 +529,7 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 		reqLogger.Info(" Driver previously encountered an error")
 		timeSinceLastConditionChange := metav1.Now().Sub(oldStatus.LastUpdate.Time.Time).Round(time.Second)
 		reqLogger.Info(fmt.Sprintf("Time since last condition change :%v", timeSinceLastConditionChange))
-		if timeSinceLastConditionChange >= constants.MaxRetryDuration {
+		if timeSinceLastConditionChange >= retry.maxDuration {
 			// Mark the driver as failed and update the condition
 			newStatus.State = constants.Failed
 			newStatus.LastUpdate = setLastStatusUpdate(oldStatus,
@@ -391,7 +539,7 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 			return logBannerAndReturn(reconcile.Result{Requeue: false}, nil, reqLogger)
 		}
 		retryInterval = time.Duration(math.Min(float64(timeSinceLastConditionChange.Nanoseconds()*2),
-			float64(constants.MaxRetryInterval.Nanoseconds())))
+			float64(retry.maxInterval.Nanoseconds())))
 	} else {
 		_ = updateStatus(ctx, instance, r, reqLogger, newStatus, oldStatus)
 	}
@@ -407,7 +555,7 @@ func Reconcile(ctx context.Context, instance csiv1.CSIDriver, request reconcile.
 func InitializeSpec(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config, reqLogger logr.Logger) (bool, error) {
 	isUpdated := false
 	reqLogger.Info("Initializing the spec")
-	isCommonSpecUpdated, err := InitializeCommonSpec(instance, driverConfig, reqLogger)
+	isCommonSpecUpdated, err := InitializeCommonSpec(instance, r, driverConfig, reqLogger)
 	isUpdated = isUpdated || isCommonSpecUpdated
 	if err != nil {
 		reqLogger.Error(err, "Failed to initialize common spec")
@@ -425,7 +573,10 @@ func InitializeSpec(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrl
 func updateInstance(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, reqLogger logr.Logger, isUpdated bool) error {
 	if isUpdated {
 		reqLogger.Info("Attempting to update CR instance")
+		// The update returns the persisted status, so keep the status computed in this reconcile
+		status := instance.GetDriverStatus().DeepCopy()
 		err := r.GetClient().Update(ctx, instance)
+		*instance.GetDriverStatus() = *status
 		if err != nil {
 			reqLogger.Error(err, "Failed to update CR instance")
 		} else {
@@ -444,7 +595,7 @@ func GetNodeEnv(driver csiv1.CSIDriver, driverConfig *ctrlconfig.Config) []corev
 	envs := driverConfig.GetNodeEnvs()
 	envs = mergeEnvironmentVars(envs, driver.GetDriver().Common.Envs)
 	envs = mergeEnvironmentVars(envs, driver.GetDriver().Node.Envs)
-	authSecretName := driver.GetDriver().AuthSecret
+	authSecretName := LocalSecretName(driver.GetDriver().AuthSecret)
 	if authSecretName != "" {
 		for i, env := range envs {
 			if env.Name == driver.GetUserEnvName() {
@@ -455,6 +606,8 @@ func GetNodeEnv(driver csiv1.CSIDriver, driverConfig *ctrlconfig.Config) []corev
 		}
 	}
 	envs = mergeEnvironmentVars(envs, GetCustomEnvVars(driver, driverConfig.ConfigVersion, envs))
+	envs = setCertValidationEnvs(driver, envs, driver.GetDriver().Common.Envs, driver.GetDriver().Node.Envs)
+	envs = setCHAPEnvs(driver, envs)
 	// Code only for PowerMax
 	if driver.GetDriverType() == csiv1.PowerMax && driverConfig.ConfigVersion != "v1" {
 		iscsiCHAPEnvName := "X_CSI_POWERMAX_ISCSI_ENABLE_CHAP"
@@ -488,7 +641,7 @@ func GetControllerEnv(driver csiv1.CSIDriver, driverConfig *ctrlconfig.Config) [
 	envs = mergeEnvironmentVars(envs, driver.GetDriver().Common.Envs)
 	// Merge with the Controller specific environment variables
 	envs = mergeEnvironmentVars(envs, driver.GetDriver().Controller.Envs)
-	authSecretName := driver.GetDriver().AuthSecret
+	authSecretName := LocalSecretName(driver.GetDriver().AuthSecret)
 	if authSecretName != "" {
 		for i, env := range envs {
 			if env.Name == driver.GetUserEnvName() {
@@ -499,6 +652,7 @@ func GetControllerEnv(driver csiv1.CSIDriver, driverConfig *ctrlconfig.Config) [
 		}
 	}
 	envs = mergeEnvironmentVars(envs, GetCustomEnvVars(driver, driverConfig.ConfigVersion, envs))
+	envs = setCertValidationEnvs(driver, envs, driver.GetDriver().Common.Envs, driver.GetDriver().Controller.Envs)
 	// Code only for PowerMax
 	if driver.GetDriverType() == csiv1.PowerMax && driverConfig.ConfigVersion != "v1" {
 		vsphereEnvName := "X_CSI_VSPHERE_ENABLED"
@@ -697,6 +851,50 @@ func ApplyDefaultsForSideCars(instance csiv1.CSIDriver, driverConfig *ctrlconfig
 	return sideCars, isUpdated, nil
 }
 
+// ApplyDefaultsForDriverImage - Applies the default driver image for the config version
+// A warning event is recorded when the tag of a driver image specified by the user doesn't match the config version
+func ApplyDefaultsForDriverImage(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config,
+	annotations map[string]string, isUpgrade bool, reqLogger logr.Logger) (bool, error) {
+	driver := instance.GetDriver()
+	imageType := string(csiv1.ImageTypeDriver)
+	defaultImage, err := driverConfig.GetDefaultImageTag(imageType)
+	if err != nil {
+		// No default driver image for this config version
+		reqLogger.Info("Default driver image not found in config. Driver image must be specified in the spec")
+		return false, nil
+	}
+	isUpdated := false
+	if driver.Common.Image == "" {
+		driver.Common.Image = defaultImage
+		isUpdated = true
+		_ = updateAnnotations(annotations, "true", imageType, defaultImage)
+	} else if driver.Common.Image != defaultImage {
+		isDefaultKey := fmt.Sprintf("%s/%s.Image.IsDefault", MetadataPrefix, imageType)
+		if isUpgrade && annotations[isDefaultKey] == "true" {
+			// The previous image was applied by the operator, so roll it forward
+			driver.Common.Image = defaultImage
+			isUpdated = true
+			_ = updateAnnotations(annotations, "true", imageType, defaultImage)
+		} else {
+			// user specified image
+			isUpdated = updateAnnotations(annotations, "false", imageType, driver.Common.Image)
+			tag := getImageTag(driver.Common.Image)
+			if tag != GetConfigVersion(instance) && (isUpdated || isUpgrade) {
+				// Only warn when the driver image or the config version changes
+				message := fmt.Sprintf("Driver image tag (%s) doesn't match the config version (%s)", tag,
+					GetConfigVersion(instance))
+				reqLogger.Info(fmt.Sprintf("Warning: %s", message))
+				recordEvent(r, instance, corev1.EventTypeWarning, constants.ReasonDriverImageMismatch, message)
+			}
+		}
+	} else {
+		// Update the annotations just in case
+		isUpdated = updateAnnotations(annotations, "true", imageType, defaultImage)
+	}
+	instance.SetAnnotations(annotations)
+	return isUpdated, nil
+}
+
 // ApplyDefaultsForInitContainers - Applies any missing defaults for InitContainers
 func ApplyDefaultsForInitContainers(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config,
 	reqLogger logr.Logger) ([]csiv1.ContainerTemplate, bool, error) {
@@ -771,7 +969,7 @@ func ApplyDefaultsForStorageClass(instance csiv1.CSIDriver, driverConfig *ctrlco
 }
 
 // InitializeCommonSpec - Initializes status and applies defaults for sidecars
-func InitializeCommonSpec(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config, reqLogger logr.Logger) (bool, error) {
+func InitializeCommonSpec(instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config, reqLogger logr.Logger) (bool, error) {
 	status := instance.GetDriverStatus()
 	isUpdated := false
 	annotations := instance.GetAnnotations()
@@ -787,7 +985,7 @@ func InitializeCommonSpec(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Con
 	// Check for the annotations with the config version and if it matches with the current one
 	if len(annotations) != 0 {
 		if configVersionFromAnnotation, ok := annotations[configVersionKey]; ok {
-			if configVersionFromAnnotation != "" && configVersionFromAnnotation != driver.ConfigVersion {
+			if configVersionFromAnnotation != "" && configVersionFromAnnotation != GetConfigVersion(instance) {
 				// This means that it is an upgrade
 				isUpgrade = true
 			}
@@ -803,6 +1001,11 @@ func InitializeCommonSpec(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Con
 		}
 	}
 
+	driverImageUpdated, err := ApplyDefaultsForDriverImage(instance, r, driverConfig, annotations, isUpgrade, reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failure during applying defaults for driver image")
+		return false, err
+	}
 	sideCars, sideCarsUpdated, err := ApplyDefaultsForSideCars(instance, driverConfig, annotations, isUpgrade, reqLogger)
 	if err != nil {
 		reqLogger.Error(err, "Failure during applying defaults for sidecars")
@@ -820,7 +1023,7 @@ func InitializeCommonSpec(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Con
 	if err != nil {
 		return false, err
 	}
-	isUpdated = annotationsUpdated || sideCarsUpdated || configVersionApplied || initContainersUpdated
+	isUpdated = annotationsUpdated || driverImageUpdated || sideCarsUpdated || configVersionApplied || initContainersUpdated
 	status.LastUpdate.ErrorMessage = ""
 	return isUpdated, nil
 }
@@ -876,55 +1079,6 @@ func GetNodeInitContainersParams(driver csiv1.CSIDriver, driverConfig *ctrlconfi
 	return initContainerMap
 }
 
-func getMultipleCertSecretVolume(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client, reqLogger logr.Logger) corev1.Volume {
-	secrets, err := secrets.GetSecrets(ctx, instance.GetNamespace(), client, reqLogger)
-	//var CertSecretCount int
-	var volume corev1.Volume
-	if err != nil {
-		return corev1.Volume{}
-	}
-	secretPrefix := fmt.Sprintf("%s-certs-", instance.GetDriverType())
-	volSources := make([]corev1.VolumeProjection, 0)
-	for i := 0; i >= 0; i++ {
-		found := false
-		for _, s := range secrets {
-			if s.Name == fmt.Sprintf("%s%d", secretPrefix, i) {
-				found = true
-				certName := fmt.Sprintf("cert-%d", i)
-				if _, ok := s.Data[certName]; ok {
-					volSources = append(volSources, corev1.VolumeProjection{
-						Secret: &corev1.SecretProjection{
-							LocalObjectReference: corev1.LocalObjectReference{Name: s.Name},
-							Items: []corev1.KeyToPath{
-								{Key: certName, Path: certName},
-							},
-						},
-					})
-				} else {
-					reqLogger.Error(fmt.Errorf("cert Secret [%s] dosen't have key [%s] in the data filed", s.Name, certName), "")
-				}
-				break
-			}
-		}
-
-		if !found {
-			break
-		}
-	}
-
-	projectedVolSource := corev1.ProjectedVolumeSource{
-		Sources: volSources,
-	}
-	volume = corev1.Volume{
-		Name: "certs",
-		VolumeSource: corev1.VolumeSource{
-			Projected: &projectedVolSource,
-		},
-	}
-
-	return volume
-}
-
 func getDummyClusterRoleName(instance csiv1.CSIDriver) string {
 	return fmt.Sprintf("%s-%s-dummy", instance.GetName(), instance.GetNamespace())
 }
@@ -936,16 +1090,11 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 	client := r.GetClient()
 	// First get the envs
 	controllerEnvs := GetControllerEnv(instance, driverConfig)
-	daemonSetEnvs := GetNodeEnv(instance, driverConfig)
 
 	controllerPodConstraints := csiv1.PodSchedulingConstraints{}
 	controllerPodConstraints.Tolerations = GetControllerTolerations(instance, driverConfig)
 	controllerPodConstraints.NodeSelector = GetControllerNodeSelector(instance)
 
-	nodePodConstraints := csiv1.PodSchedulingConstraints{}
-	nodePodConstraints.Tolerations = GetNodeTolerations(instance, driverConfig)
-	nodePodConstraints.NodeSelector = GetNodeNodeSelector(instance)
-
 	// Get the name only using one set of env values
 	customDriverName := GetCustomDriverName(instance, driverConfig.ConfigVersion, controllerEnvs, reqLogger)
 	customRBACNames := false
@@ -978,7 +1127,7 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 		return err
 	}
 	isOpenshift := r.GetConfig().IsOpenShift
-	isLimitedNodeRBAC := IsLimitedNodeRBAC(instance.GetDriverType(), instance.GetDriver().ConfigVersion)
+	isLimitedNodeRBAC := IsLimitedNodeRBAC(instance.GetDriverType(), GetConfigVersion(instance))
 	createServiceAccount := false
 	if !isLimitedNodeRBAC {
 		createServiceAccount = true
@@ -1044,11 +1193,16 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 	}
 
 	multipleCertSecretVolume := make([]corev1.Volume, 0)
-	if instance.GetDriverType() == csiv1.Unity || instance.GetDriverType() == csiv1.Isilon {
-		certVolume := getMultipleCertSecretVolume(ctx, instance, client, reqLogger)
-		if certVolume.Name != "" {
-			multipleCertSecretVolume = append(multipleCertSecretVolume, certVolume)
-		}
+	trustBundle, err := syncTrustBundleSecret(ctx, instance, client, reqLogger)
+	if err != nil {
+		return err
+	}
+	certVolume, err := getMultipleCertSecretVolume(ctx, instance, client, trustBundle, reqLogger)
+	if err != nil {
+		return err
+	}
+	if certVolume.Name != "" {
+		multipleCertSecretVolume = append(multipleCertSecretVolume, certVolume)
 	}
 	if len(multipleCertSecretVolume) != 0 {
 		controllerVolumes = mergeVolumes(controllerVolumes, multipleCertSecretVolume)
@@ -1062,6 +1216,11 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 	if driverConfig.DriverConfig.ControllerHA {
 		deploy := deployment.New(instance, controllerEnvs, controllerVolumeMounts,
 			controllerVolumes, args, sidecarMap, controllerPodConstraints)
+		resources.SetPodTemplateRestartNonce(&deploy.Spec.Template, instance.GetDriver().RestartNonce)
+		err = setPodTemplateSecretsChecksum(ctx, instance, &deploy.Spec.Template, client)
+		if err != nil {
+			return err
+		}
 
 		err = deployment.SyncControllerDeployment(ctx, deploy, client, reqLogger)
 		if err != nil {
@@ -1081,6 +1240,11 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 	} else {
 		ss := statefulset.New(instance, controllerEnvs, controllerVolumeMounts,
 			controllerVolumes, args, sidecarMap, controllerPodConstraints)
+		resources.SetPodTemplateRestartNonce(&ss.Spec.Template, instance.GetDriver().RestartNonce)
+		err = setPodTemplateSecretsChecksum(ctx, instance, &ss.Spec.Template, client)
+		if err != nil {
+			return err
+		}
 
 		err = statefulset.SyncStatefulset(ctx, ss, client, reqLogger)
 		if err != nil {
@@ -1088,24 +1252,119 @@ func SyncDriver(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, d
 		}
 	}
 
+	// Generate the CHAP secret referenced by the Node plugin (if requested)
+	err = syncCHAPSecret(ctx, instance, r, reqLogger)
+	if err != nil {
+		return err
+	}
 	// Create daemonset
+	ds, err := newNodeDaemonSet(instance, driverConfig, multipleCertSecretVolume, createServiceAccount, reqLogger)
+	if err != nil {
+		return err
+	}
+	// Create the canary daemonset (if requested)
+	canaryDs, err := newCanaryDaemonSet(instance, r, driverConfig, multipleCertSecretVolume, createServiceAccount, reqLogger)
+	if err != nil {
+		return err
+	}
+	if canaryDs != nil {
+		daemonset.ExcludeNodes(ds, instance.GetDriver().Node.Canary.NodeSelector)
+		err = setPodTemplateSecretsChecksum(ctx, instance, &canaryDs.Spec.Template, client)
+		if err != nil {
+			return err
+		}
+	}
+	err = setPodTemplateSecretsChecksum(ctx, instance, &ds.Spec.Template, client)
+	if err != nil {
+		return err
+	}
+	if isVolumeAwareRollout(instance) {
+		// The checksum is used to find the node pods which need to be restarted
+		err = resources.SetPodTemplateChecksum(ctx, &ds.Spec.Template, ds.Namespace, client)
+		if err != nil {
+			return err
+		}
+	}
+	err = daemonset.SyncDaemonset(ctx, ds, client, reqLogger)
+	if err != nil {
+		return err
+	}
+	if canaryDs != nil {
+		return daemonset.SyncDaemonset(ctx, canaryDs, client, reqLogger)
+	}
+	return daemonset.DeleteDaemonset(ctx, daemonset.GetCanaryName(instance.GetDaemonSetName()),
+		instance.GetNamespace(), client, reqLogger)
+}
+
+// setPodTemplateSecretsChecksum - Annotates the pod template with a checksum of the referenced Secrets
+// unless the rollout of the driver pods on a rotation of the Secrets is disabled
+func setPodTemplateSecretsChecksum(ctx context.Context, instance csiv1.CSIDriver, template *corev1.PodTemplateSpec,
+	client crclient.Client) error {
+	if instance.GetDriver().DisableSecretRollout {
+		return nil
+	}
+	return resources.SetPodTemplateSecretsChecksum(ctx, template, instance.GetNamespace(), client)
+}
+
+// isSecretRolloutDue - Returns true if the content of the Secrets referenced by the controller or the node pods
+// changed since the driver workloads were last synced, unless the rollout of the driver pods on a rotation of the
+// Secrets is disabled
+func isSecretRolloutDue(ctx context.Context, instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config,
+	client crclient.Client) bool {
+	if instance.GetDriver().DisableSecretRollout {
+		return false
+	}
+	templates := make([]*corev1.PodTemplateSpec, 0)
+	controllerKey := types.NamespacedName{Name: instance.GetControllerName(), Namespace: instance.GetNamespace()}
+	if driverConfig.DriverConfig == nil || driverConfig.DriverConfig.ControllerHA {
+		controller := &appsv1.Deployment{}
+		if err := client.Get(ctx, controllerKey, controller); err == nil {
+			templates = append(templates, &controller.Spec.Template)
+		}
+	} else {
+		controller := &appsv1.StatefulSet{}
+		if err := client.Get(ctx, controllerKey, controller); err == nil {
+			templates = append(templates, &controller.Spec.Template)
+		}
+	}
+	for _, name := range []string{instance.GetDaemonSetName(), daemonset.GetCanaryName(instance.GetDaemonSetName())} {
+		node := &appsv1.DaemonSet{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.GetNamespace()}, node)
+		if err == nil {
+			templates = append(templates, &node.Spec.Template)
+		}
+	}
+	for _, template := range templates {
+		checksum, err := resources.GetSecretsChecksum(ctx, template.Spec, instance.GetNamespace(), client)
+		if err == nil && checksum != template.Annotations[constants.PodTemplateSecretsChecksumKey] {
+			return true
+		}
+	}
+	return false
+}
+
+// newNodeDaemonSet - Returns the daemonset for the Node plugin
+func newNodeDaemonSet(instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config, certVolumes []corev1.Volume,
+	createServiceAccount bool, reqLogger logr.Logger) (*appsv1.DaemonSet, error) {
+	daemonSetEnvs := GetNodeEnv(instance, driverConfig)
+	nodePodConstraints := csiv1.PodSchedulingConstraints{}
+	nodePodConstraints.Tolerations = GetNodeTolerations(instance, driverConfig)
+	nodePodConstraints.NodeSelector = GetNodeNodeSelector(instance)
 	daemonSetVolumes := driverConfig.GetNodeVolumes()
-	if len(multipleCertSecretVolume) != 0 {
-		daemonSetVolumes = mergeVolumes(daemonSetVolumes, multipleCertSecretVolume)
+	if len(certVolumes) != 0 {
+		daemonSetVolumes = mergeVolumes(daemonSetVolumes, certVolumes)
 	}
 	daemonSetDriverVolumeMounts := driverConfig.GetNodeVolumeMounts()
 
-	args = GetNodeArgs(instance, driverConfig)
+	args := GetNodeArgs(instance, driverConfig)
+	sidecarMap := GetSideCarParams(instance, driverConfig, reqLogger)
 	reqLogger.Info("calling GetInitContainerParams")
 	nodeInitContainers := GetNodeInitContainersParams(instance, driverConfig)
 	ds, err := daemonset.New(instance, daemonSetEnvs, daemonSetDriverVolumeMounts, daemonSetVolumes,
 		args, nodeInitContainers, sidecarMap, createServiceAccount, nodePodConstraints, reqLogger)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = daemonset.SyncDaemonset(ctx, ds, client, reqLogger)
-	if err != nil {
-		return err
-	}
-	return nil
+	resources.SetPodTemplateRestartNonce(&ds.Spec.Template, instance.GetDriver().RestartNonce)
+	return ds, nil
 }
@@ -0,0 +1,173 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+)
+
+// Kinds of the objects tracked as dependencies of a driver
+const (
+	DependencyKindSecret    = "Secret"
+	DependencyKindConfigMap = "ConfigMap"
+)
+
+// MissingDependencyError - Error returned when an object required by the driver doesn't exist
+type MissingDependencyError struct {
+	Kind string
+	Name string
+	Err  error
+}
+
+// NewMissingSecretError - Returns an error which marks the secret as a missing dependency of the driver
+func NewMissingSecretError(name string, err error) error {
+	return &MissingDependencyError{Kind: DependencyKindSecret, Name: name, Err: err}
+}
+
+// Error - Returns the wrapped error message
+func (e *MissingDependencyError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - Returns the wrapped error
+func (e *MissingDependencyError) Unwrap() error {
+	return e.Err
+}
+
+// DependencyIndexValue - Returns the value used to index a driver by one of its missing dependencies
+func DependencyIndexValue(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// IsMissingDependency - Returns true if the error was caused by an object required by the driver not existing
+func IsMissingDependency(err error) bool {
+	var missing *MissingDependencyError
+	return errors.As(err, &missing)
+}
+
+// getMissingDependencies - Returns every missing dependency recorded in the error (if any)
+func getMissingDependencies(err error) []csiv1.Dependency {
+	var dependencies []csiv1.Dependency
+	var collect func(err error)
+	collect = func(err error) {
+		switch e := err.(type) {
+		case *MissingDependencyError:
+			dependency := csiv1.Dependency{Kind: e.Kind, Name: e.Name}
+			for _, d := range dependencies {
+				if d == dependency {
+					return
+				}
+			}
+			dependencies = append(dependencies, dependency)
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range e.Unwrap() {
+				collect(wrapped)
+			}
+		case interface{ Unwrap() error }:
+			collect(e.Unwrap())
+		}
+	}
+	collect(err)
+	return dependencies
+}
+
+// isOnlyMissingDependencies - Returns true if the error was only caused by objects required by the driver not existing
+func isOnlyMissingDependencies(err error) bool {
+	switch e := err.(type) {
+	case *MissingDependencyError:
+		return true
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			if !isOnlyMissingDependencies(wrapped) {
+				return false
+			}
+		}
+		return true
+	case interface{ Unwrap() error }:
+		return isOnlyMissingDependencies(e.Unwrap())
+	}
+	return false
+}
+
+// missingDependencies - Collects the errors of the missing dependencies of a driver so that all of them are
+// reported at once instead of only the first one
+type missingDependencies struct {
+	errs []error
+}
+
+// add - Records the error if it was only caused by missing dependencies. The dependencies which were already
+// recorded are skipped. Returns false (without recording it) for any other error
+func (m *missingDependencies) add(err error) bool {
+	if !isOnlyMissingDependencies(err) {
+		return false
+	}
+	if errs, ok := err.(validationErrors); ok {
+		for _, e := range errs {
+			m.add(e)
+		}
+		return true
+	}
+	recorded := getMissingDependencies(validationErrors(m.errs))
+	for _, dependency := range getMissingDependencies(err) {
+		isRecorded := false
+		for _, d := range recorded {
+			if d == dependency {
+				isRecorded = true
+				break
+			}
+		}
+		if !isRecorded {
+			m.errs = append(m.errs, err)
+			break
+		}
+	}
+	return true
+}
+
+// join - Returns the recorded errors along with err (if not nil). Returns nil if there is no error at all
+func (m *missingDependencies) join(err error) error {
+	errs := append([]error{}, m.errs...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return validationErrors(errs)
+}
+
+// validationErrors - Errors found while validating the driver which are reported together
+type validationErrors []error
+
+// Error - Returns the messages of the errors separated by semicolons
+func (e validationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap - Returns the errors
+func (e validationErrors) Unwrap() []error {
+	return e
+}
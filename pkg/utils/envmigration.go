@@ -0,0 +1,89 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// migrateDeprecatedEnvs - Renames the deprecated environment variables in the driver spec to their replacements
+// and records a warning in the driver status. The driver spec is rewritten with the new names the next time
+// the CR instance is updated. Returns an error if a removed env without a replacement is set
+func migrateDeprecatedEnvs(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config,
+	reqLogger logr.Logger) error {
+	if driverConfig.DriverConfig == nil {
+		return nil
+	}
+	replacements := driverConfig.DriverConfig.GetEnvReplacements()
+	if len(replacements) == 0 {
+		return nil
+	}
+	driver := instance.GetDriver()
+	renamed := make([]string, 0)
+	for _, template := range []*csiv1.ContainerTemplate{&driver.Common, &driver.Controller, &driver.Node.ContainerTemplate} {
+		envs := make([]corev1.EnvVar, 0, len(template.Envs))
+		for _, env := range template.Envs {
+			replacement, ok := replacements[env.Name]
+			if !ok {
+				envs = append(envs, env)
+				continue
+			}
+			if replacement == "" {
+				return fmt.Errorf("environment variable %s is no longer supported by the driver", env.Name)
+			}
+			renamed = appendIfMissingString(renamed, fmt.Sprintf("%s (replaced by %s)", env.Name, replacement))
+			if present, _ := isEnvPresent(replacement, template.Envs); present {
+				// The new name takes precedence
+				continue
+			}
+			env.Name = replacement
+			envs = append(envs, env)
+		}
+		template.Envs = envs
+	}
+	if len(renamed) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("Deprecated environment variables in the driver spec: %s", strings.Join(renamed, ", "))
+	reqLogger.Info(fmt.Sprintf("Warning: %s", message))
+	recordEvent(r, instance, corev1.EventTypeWarning, constants.ReasonDeprecatedEnvs, message)
+	status := instance.GetDriverStatus()
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               constants.ConditionDeprecatedEnvs,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.GetGeneration(),
+		Reason:             constants.ReasonDeprecatedEnvs,
+		Message:            message,
+	})
+	// The spec is only updated in memory, so persist just the status here
+	spec := instance.GetDriver().DeepCopy()
+	err := r.GetClient().Status().Update(ctx, instance)
+	*instance.GetDriver() = *spec
+	if err != nil {
+		// Not fatal. Only the warning in the status is lost
+		reqLogger.Error(err, "Failed to update CR status")
+	}
+	return nil
+}
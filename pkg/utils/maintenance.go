@@ -0,0 +1,274 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the time zone database so that time zones can be loaded from minimal images
+	_ "time/tzdata"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyNowKey - annotation used to apply a change to the driver spec outside of the maintenance windows
+var applyNowKey = fmt.Sprintf("%s/%s", MetadataPrefix, "apply-now")
+
+// maxScheduleSearchYears - Number of years searched for the next start of a maintenance window
+// Large enough to cover schedules which only match on leap days
+const maxScheduleSearchYears = 9
+
+// cronSchedule - Parsed cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar & dowStar are set if day-of-month and day-of-week were not restricted
+	domStar, dowStar bool
+}
+
+// maintenanceWindow - Parsed maintenance window
+type maintenanceWindow struct {
+	schedule *cronSchedule
+	duration time.Duration
+	location *time.Location
+}
+
+// parseCronField - Parses a cron field made of a list of values, ranges & steps
+func parseCronField(field string, min, max int) (uint64, bool, error) {
+	var bits uint64
+	star := false
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("invalid step in %s", part)
+			}
+		}
+		start, end := min, max
+		if rangePart == "*" {
+			// A step of 1 doesn't restrict the field
+			star = !hasStep || step == 1
+		} else {
+			low, high, isRange := strings.Cut(rangePart, "-")
+			var err error
+			start, err = strconv.Atoi(low)
+			if err != nil {
+				return 0, false, fmt.Errorf("invalid value in %s", part)
+			}
+			if isRange {
+				end, err = strconv.Atoi(high)
+				if err != nil {
+					return 0, false, fmt.Errorf("invalid value in %s", part)
+				}
+			} else if !hasStep {
+				end = start
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, false, fmt.Errorf("%s is out of range [%d-%d]", part, min, max)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, star, nil
+}
+
+// parseSchedule - Parses a cron expression with 5 fields (minute hour day-of-month month day-of-week)
+func parseSchedule(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in schedule %q, found %d", spec, len(fields))
+	}
+	var err error
+	schedule := &cronSchedule{}
+	if schedule.minute, _, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if schedule.hour, _, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if schedule.dom, schedule.domStar, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if schedule.month, _, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if schedule.dow, schedule.dowStar, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// Both 0 & 7 are Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	return schedule, nil
+}
+
+// dayMatches - Returns true if the day matches the schedule
+// If both day-of-month and day-of-week are restricted, either of them has to match
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// dayStart - Returns the first minute of the day
+// time.Date moves a midnight skipped by a DST switch to the previous day, so such a day starts at the end of the switch
+func dayStart(year int, month time.Month, day int, location *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, location)
+	noon := time.Date(year, month, day, 12, 0, 0, 0, location)
+	for t.Day() != noon.Day() {
+		t = t.Add(time.Minute)
+	}
+	return t
+}
+
+// next - Returns the first time after t which matches the schedule
+// The search only moves forward in absolute time, so local times skipped by a DST switch never match
+func (s *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + maxScheduleSearchYears
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = dayStart(t.Year(), t.Month()+1, 1, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = dayStart(t.Year(), t.Month(), t.Day()+1, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseMaintenanceWindow - Parses & validates a maintenance window
+func parseMaintenanceWindow(window csiv1.MaintenanceWindow) (*maintenanceWindow, error) {
+	schedule, err := parseSchedule(window.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if window.Duration.Duration <= 0 {
+		return nil, fmt.Errorf("duration of the window with schedule %q must be greater than 0", window.Schedule)
+	}
+	location := time.UTC
+	if window.TimeZone != "" {
+		location, err = time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %s: %v", window.TimeZone, err)
+		}
+	}
+	if _, ok := schedule.next(time.Now().In(location)); !ok {
+		return nil, fmt.Errorf("schedule %q never matches", window.Schedule)
+	}
+	return &maintenanceWindow{
+		schedule: schedule,
+		duration: window.Duration.Duration,
+		location: location,
+	}, nil
+}
+
+// validateMaintenanceWindows - Validates the maintenance windows in the driver spec
+func validateMaintenanceWindows(windows []csiv1.MaintenanceWindow) error {
+	for _, window := range windows {
+		if _, err := parseMaintenanceWindow(window); err != nil {
+			return fmt.Errorf("invalid maintenance window: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetNextMaintenanceWindow - Returns true if now is inside one of the maintenance windows
+// Otherwise returns the start time of the next maintenance window
+func GetNextMaintenanceWindow(windows []csiv1.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	var nextStart time.Time
+	for _, w := range windows {
+		window, err := parseMaintenanceWindow(w)
+		if err != nil {
+			return false, nextStart, fmt.Errorf("invalid maintenance window: %v", err)
+		}
+		localNow := now.In(window.location)
+		// The last window start is within the duration of the window
+		if start, ok := window.schedule.next(localNow.Add(-window.duration)); ok && !start.After(localNow) {
+			return true, start, nil
+		}
+		if start, ok := window.schedule.next(localNow); ok && (nextStart.IsZero() || start.Before(nextStart)) {
+			nextStart = start
+		}
+	}
+	return false, nextStart, nil
+}
+
+// deferToMaintenanceWindow - Checks if a change to the driver spec has to wait for a maintenance window
+// Records the pending update in the status & returns the time till the next window start if the change is deferred
+func deferToMaintenanceWindow(instance csiv1.CSIDriver, r ReconcileCSI, expectedHash uint64,
+	newStatus, oldStatus *csiv1.DriverStatus, reqLogger logr.Logger) (time.Duration, error) {
+	windows := instance.GetDriver().MaintenanceWindows
+	if len(windows) == 0 {
+		return 0, nil
+	}
+	annotations := instance.GetAnnotations()
+	if annotations[applyNowKey] == "true" {
+		// Emergency change. The annotation is removed when the CR instance is updated
+		delete(annotations, applyNowKey)
+		instance.SetAnnotations(annotations)
+		message := "Applying the change to the driver spec outside of the maintenance windows as requested"
+		reqLogger.Info(message)
+		recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonUpdateAppliedNow, message)
+		return 0, nil
+	}
+	now := time.Now()
+	inWindow, nextStart, err := GetNextMaintenanceWindow(windows, now)
+	if err != nil {
+		return 0, err
+	}
+	if inWindow {
+		reqLogger.Info(fmt.Sprintf("Maintenance window started at %s is active", nextStart.Format(time.RFC3339)))
+		return 0, nil
+	}
+	message := fmt.Sprintf("Change to the driver spec will be applied in the next maintenance window at %s",
+		nextStart.Format(time.RFC3339))
+	reqLogger.Info(message)
+	nextWindow := metav1.NewTime(nextStart)
+	newStatus.PendingUpdate = &csiv1.PendingUpdate{
+		DriverHash: expectedHash,
+		NextWindow: &nextWindow,
+		Message:    message,
+	}
+	if oldStatus.PendingUpdate == nil || oldStatus.PendingUpdate.DriverHash != expectedHash {
+		recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonUpdateDeferred, message)
+	}
+	return nextStart.Sub(now), nil
+}
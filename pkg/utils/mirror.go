@@ -0,0 +1,244 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SplitSecretRef - Splits a secret reference of the form [namespace/]name
+// The namespace is empty if the reference doesn't specify one
+func SplitSecretRef(ref string) (string, string) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// LocalSecretName - Returns the name of the secret in the namespace of the CR for the secret reference
+// Secrets referenced from another namespace are mirrored with the same name
+func LocalSecretName(ref string) string {
+	_, name := SplitSecretRef(ref)
+	return name
+}
+
+// isMirroredSecretRef - Returns true if the secret reference points to a namespace other than the namespace of the CR
+func isMirroredSecretRef(ref, namespace string) bool {
+	sourceNamespace, _ := SplitSecretRef(ref)
+	return sourceNamespace != "" && sourceNamespace != namespace
+}
+
+// ValidateSecretRef - Validates a secret reference of the form [namespace/]name
+// Secrets can only be referenced from the namespaces in the allowlist of the operator
+func ValidateSecretRef(ref, namespace string, allowedNamespaces []string) error {
+	sourceNamespace, name := SplitSecretRef(ref)
+	if name == "" || strings.Contains(name, "/") || (strings.Contains(ref, "/") && sourceNamespace == "") {
+		return fmt.Errorf("invalid secret reference %s: must be of the form [namespace/]name", ref)
+	}
+	if !isMirroredSecretRef(ref, namespace) {
+		return nil
+	}
+	for _, allowed := range allowedNamespaces {
+		if allowed == sourceNamespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid secret reference %s: secrets can't be referenced from namespace %s", ref, sourceNamespace)
+}
+
+// ValidateSecretRefs - Validates the secret references of a CR
+func ValidateSecretRefs(refs []string, namespace string, allowedNamespaces []string) error {
+	for _, ref := range refs {
+		err := ValidateSecretRef(ref, namespace, allowedNamespaces)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDriverSecretRefs - Returns the secret references in the driver spec
+func GetDriverSecretRefs(instance csiv1.CSIDriver) []string {
+	driver := instance.GetDriver()
+	refs := make([]string, 0)
+	if driver.AuthSecret != "" {
+		refs = append(refs, driver.AuthSecret)
+	}
+	refs = append(refs, driver.CertSecrets...)
+	if bundle := driver.TrustBundle; bundle != nil && bundle.SecretKeyRef != nil {
+		refs = append(refs, bundle.SecretKeyRef.Name)
+	}
+	return refs
+}
+
+// MirrorSecrets - Copies the secrets referenced from other namespaces to the namespace of the owner & keeps the
+// copies up to date. The copies are owned by the owner (without being controlled by it) so that they are deleted
+// along with the last CR referencing them. The owner is removed from the copies it no longer references
+// Returns a MissingDependencyError for each of the source secrets which don't exist
+func MirrorSecrets(ctx context.Context, owner metav1.OwnerReference, namespace string, refs []string,
+	allowedNamespaces []string, client crclient.Client, reqLogger logr.Logger) error {
+	err := ValidateSecretRefs(refs, namespace, allowedNamespaces)
+	if err != nil {
+		return err
+	}
+	err = pruneMirroredSecrets(ctx, owner, namespace, refs, client, reqLogger)
+	if err != nil {
+		return err
+	}
+	missing := &missingDependencies{}
+	for _, ref := range refs {
+		if !isMirroredSecretRef(ref, namespace) {
+			continue
+		}
+		sourceNamespace, name := SplitSecretRef(ref)
+		source := &corev1.Secret{}
+		err = client.Get(ctx, types.NamespacedName{Name: name, Namespace: sourceNamespace}, source)
+		if k8serror.IsNotFound(err) {
+			missing.add(NewMissingSecretError(ref, fmt.Errorf("failed to find secret %s in namespace %s", name, sourceNamespace)))
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = mirrorSecret(ctx, owner, namespace, ref, source, client, reqLogger)
+		if err != nil {
+			return err
+		}
+	}
+	return missing.join(nil)
+}
+
+// mirrorSecret - Creates or updates the copy of the source secret in the namespace
+func mirrorSecret(ctx context.Context, owner metav1.OwnerReference, namespace, ref string, source *corev1.Secret,
+	client crclient.Client, reqLogger logr.Logger) error {
+	found := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{Name: source.Name, Namespace: namespace}, found)
+	if k8serror.IsNotFound(err) {
+		reqLogger.Info("Mirroring the secret", "Source", ref, "Namespace", namespace)
+		mirror := &corev1.Secret{
+			Type: source.Type,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            source.Name,
+				Namespace:       namespace,
+				Labels:          source.Labels,
+				Annotations:     map[string]string{constants.MirroredFromAnnotation: ref},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Data: source.Data,
+		}
+		return client.Create(ctx, mirror)
+	} else if err != nil {
+		return err
+	}
+	if found.Annotations[constants.MirroredFromAnnotation] != ref {
+		return fmt.Errorf("secret %s already exists in namespace %s & isn't a copy of %s", source.Name, namespace, ref)
+	}
+	updated := false
+	if !reflect.DeepEqual(found.Data, source.Data) {
+		found.Data = source.Data
+		updated = true
+	}
+	// The labels are copied so that the copies match the label selectors of the CRs
+	if !reflect.DeepEqual(found.Labels, source.Labels) {
+		found.Labels = source.Labels
+		updated = true
+	}
+	if getOwnerIndex(found.OwnerReferences, owner) < 0 {
+		// The copy is shared by all the CRs in the namespace referencing the source secret
+		found.OwnerReferences = append(found.OwnerReferences, owner)
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	reqLogger.Info("Updating the mirrored secret", "Source", ref, "Namespace", namespace)
+	return client.Update(ctx, found)
+}
+
+// getOwnerIndex - Returns the index of the owner in the owner references or -1 if it isn't one of them
+func getOwnerIndex(ownerReferences []metav1.OwnerReference, owner metav1.OwnerReference) int {
+	for i, ownerRef := range ownerReferences {
+		if ownerRef.UID == owner.UID && ownerRef.Kind == owner.Kind && ownerRef.Name == owner.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+// pruneMirroredSecrets - Removes the owner from the copies of the secrets it no longer references
+// Copies without any owner left are deleted
+func pruneMirroredSecrets(ctx context.Context, owner metav1.OwnerReference, namespace string, refs []string,
+	client crclient.Client, reqLogger logr.Logger) error {
+	secretList := &corev1.SecretList{}
+	err := client.List(ctx, secretList, crclient.InNamespace(namespace))
+	if err != nil {
+		return err
+	}
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		ref, ok := secret.Annotations[constants.MirroredFromAnnotation]
+		if !ok || isStringInSlice(ref, refs) {
+			continue
+		}
+		index := getOwnerIndex(secret.OwnerReferences, owner)
+		if index < 0 {
+			continue
+		}
+		secret.OwnerReferences = append(secret.OwnerReferences[:index], secret.OwnerReferences[index+1:]...)
+		if len(secret.OwnerReferences) == 0 {
+			reqLogger.Info("Deleting the mirrored secret which is no longer referenced", "Source", ref,
+				"Namespace", namespace)
+			err = client.Delete(ctx, secret)
+			if err != nil && !k8serror.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		reqLogger.Info("Removing the owner of the mirrored secret which it no longer references", "Source", ref,
+			"Namespace", namespace, "Owner", owner.Name)
+		err = client.Update(ctx, secret)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMirrorOwnerReference - Returns the reference to the CR set on the secrets mirrored for it
+func GetMirrorOwnerReference(ownerReferences []metav1.OwnerReference) metav1.OwnerReference {
+	owner := ownerReferences[0]
+	owner.Controller = nil
+	return owner
+}
+
+// mirrorDriverSecrets - Mirrors the secrets referenced from other namespaces in the driver spec
+func mirrorDriverSecrets(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, reqLogger logr.Logger) error {
+	owner := GetMirrorOwnerReference(resources.GetOwnerReferences(instance))
+	return MirrorSecrets(ctx, owner, instance.GetNamespace(), GetDriverSecretRefs(instance),
+		r.GetConfig().SecretSourceNamespaces, r.GetClient(), reqLogger)
+}
@@ -0,0 +1,74 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+)
+
+// retryPolicy - Effective policy used to retry the deployment of a driver
+type retryPolicy struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	maxDuration time.Duration
+}
+
+// getRetryPolicy - Returns the retry policy for the driver
+// The policy in the driver spec takes priority over the one configured for the operator
+func getRetryPolicy(instance csiv1.CSIDriver, r ReconcileCSI) retryPolicy {
+	policy := retryPolicy{
+		interval:    constants.DefaultRetryInterval,
+		maxDuration: constants.DefaultMaxRetryDuration,
+	}
+	operatorConfig := r.GetConfig()
+	if operatorConfig.RetryInterval > 0 {
+		policy.interval = operatorConfig.RetryInterval
+	}
+	if operatorConfig.MaxRetryDuration > 0 {
+		policy.maxDuration = operatorConfig.MaxRetryDuration
+	}
+	if driverPolicy := instance.GetDriver().RetryPolicy; driverPolicy != nil {
+		if driverPolicy.Interval != nil && driverPolicy.Interval.Duration > 0 {
+			policy.interval = driverPolicy.Interval.Duration
+		}
+		if driverPolicy.MaxDuration != nil && driverPolicy.MaxDuration.Duration > 0 {
+			policy.maxDuration = driverPolicy.MaxDuration.Duration
+		}
+	}
+	// The interval grows till MaxRetryInterval unless a larger interval was requested
+	policy.maxInterval = constants.MaxRetryInterval
+	if policy.interval > policy.maxInterval {
+		policy.maxInterval = policy.interval
+	}
+	return policy
+}
+
+// validateRetryPolicy - Validates the retry policy in the driver spec
+func validateRetryPolicy(policy *csiv1.RetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.Interval != nil && policy.Interval.Duration <= 0 {
+		return fmt.Errorf("invalid retry policy: interval must be greater than 0")
+	}
+	if policy.MaxDuration != nil && policy.MaxDuration.Duration <= 0 {
+		return fmt.Errorf("invalid retry policy: max duration must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/resources"
+	"github.com/dell/dell-csi-operator/pkg/resources/configmap"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// rollbackKey - annotation used to request a rollback of the driver spec to a revision
+var rollbackKey = fmt.Sprintf("%s/%s", MetadataPrefix, "rollback-to")
+
+const revisionKeyPrefix = "revision-"
+
+// DriverRevision - Represents a driver spec which was successfully Running
+type DriverRevision struct {
+	Revision      int64             `json:"revision"`
+	Time          metav1.Time       `json:"time"`
+	DriverHash    uint64            `json:"driverHash"`
+	ConfigVersion string            `json:"configVersion"`
+	DriverVersion string            `json:"driverVersion"`
+	Images        map[string]string `json:"images"`
+	Driver        csiv1.Driver      `json:"driver"`
+}
+
+// getRevisionHistoryName - Returns the name of the configmap which stores the revision history
+func getRevisionHistoryName(instance csiv1.CSIDriver) string {
+	return fmt.Sprintf("%s-revision-history", instance.GetName())
+}
+
+// getDriverRevisions - Returns the revisions stored for the driver, sorted from oldest to newest
+func getDriverRevisions(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI) ([]DriverRevision, error) {
+	found := &corev1.ConfigMap{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: getRevisionHistoryName(instance),
+		Namespace: instance.GetNamespace()}, found)
+	if err != nil {
+		if k8serror.IsNotFound(err) {
+			return []DriverRevision{}, nil
+		}
+		return nil, err
+	}
+	revisions := make([]DriverRevision, 0)
+	for key, value := range found.Data {
+		if !strings.HasPrefix(key, revisionKeyPrefix) {
+			continue
+		}
+		var revision DriverRevision
+		err = json.Unmarshal([]byte(value), &revision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s in revision history: %v", key, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// recordDriverRevision - Stores the current driver spec in the revision history
+// if it is different from the latest stored revision
+func recordDriverRevision(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config,
+	newStatus *csiv1.DriverStatus, reqLogger logr.Logger) {
+	revisions, err := getDriverRevisions(ctx, instance, r)
+	if err != nil {
+		reqLogger.Error(err, "Failed to read the revision history")
+		return
+	}
+	driverHash := HashDriver(instance)
+	latestRevision := int64(0)
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		latestRevision = latest.Revision
+		if latest.DriverHash == driverHash {
+			newStatus.Revision = latestRevision
+			return
+		}
+	}
+	driver := instance.GetDriver()
+	images := map[string]string{string(csiv1.ImageTypeDriver): driver.Common.Image}
+	for _, container := range append(driver.SideCars, driver.InitContainers...) {
+		images[string(container.Name)] = container.Image
+	}
+	revisions = append(revisions, DriverRevision{
+		Revision:      latestRevision + 1,
+		Time:          metav1.Now(),
+		DriverHash:    driverHash,
+		ConfigVersion: driverConfig.ConfigVersion,
+		DriverVersion: driverConfig.DriverVersion,
+		Images:        images,
+		Driver:        *driver.DeepCopy(),
+	})
+	if len(revisions) > constants.DriverRevisionHistoryLimit {
+		revisions = revisions[len(revisions)-constants.DriverRevisionHistoryLimit:]
+	}
+	data := make(map[string]string)
+	for _, revision := range revisions {
+		revisionJSON, err := json.Marshal(revision)
+		if err != nil {
+			reqLogger.Error(err, "Failed to marshal driver revision")
+			return
+		}
+		data[fmt.Sprintf("%s%d", revisionKeyPrefix, revision.Revision)] = string(revisionJSON)
+	}
+	revisionHistory := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            getRevisionHistoryName(instance),
+			Namespace:       instance.GetNamespace(),
+			OwnerReferences: resources.GetOwnerReferences(instance),
+		},
+		Data: data,
+	}
+	err = configmap.SyncConfigMap(ctx, revisionHistory, r.GetClient(), reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the revision history")
+		return
+	}
+	reqLogger.Info(fmt.Sprintf("Recorded revision %d of the driver spec", latestRevision+1))
+	newStatus.Revision = latestRevision + 1
+}
+
+// applyRollback - Restores the driver spec from the revision history if a rollback was requested
+// Returns true if a rollback was requested
+func applyRollback(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, reqLogger logr.Logger) (bool, error) {
+	annotations := instance.GetAnnotations()
+	requestedRevision, ok := annotations[rollbackKey]
+	if !ok {
+		return false, nil
+	}
+	delete(annotations, rollbackKey)
+	var driverRevision *DriverRevision
+	revision, err := strconv.ParseInt(requestedRevision, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid revision: %s", requestedRevision)
+	} else {
+		driverRevision, err = getDriverRevision(ctx, instance, r, revision)
+	}
+	condition := metav1.Condition{
+		Type:               constants.ConditionRollback,
+		ObservedGeneration: instance.GetGeneration(),
+	}
+	eventType := corev1.EventTypeNormal
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = constants.ReasonRollbackFailed
+		condition.Message = fmt.Sprintf("Rollback to revision %s failed: %s", requestedRevision, err.Error())
+		eventType = corev1.EventTypeWarning
+	} else {
+		*instance.GetDriver() = *driverRevision.Driver.DeepCopy()
+		// A driver which followed a channel is pinned to the config version the channel resolved to
+		instance.GetDriver().ConfigVersion = driverRevision.ConfigVersion
+		// Restoring a revision is neither an upgrade nor subject to the upgrade paths
+		annotations[configVersionKey] = driverRevision.ConfigVersion
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = constants.ReasonRollbackSucceeded
+		condition.Message = fmt.Sprintf("Driver spec rolled back to revision %d (config version %s)",
+			driverRevision.Revision, driverRevision.ConfigVersion)
+		policy := instance.GetDriver().UpgradePolicy
+		if policy != "" && policy != csiv1.UpgradePolicyManual {
+			// Don't let the upgrade policy undo the rollback
+			instance.GetDriver().UpgradePolicy = csiv1.UpgradePolicyManual
+			condition.Message = fmt.Sprintf("%s. Upgrade policy changed from %s to %s", condition.Message,
+				policy, csiv1.UpgradePolicyManual)
+		}
+	}
+	reqLogger.Info(condition.Message)
+	instance.SetAnnotations(annotations)
+	err = r.GetClient().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update CR instance")
+		return true, err
+	}
+	recordEvent(r, instance, eventType, condition.Reason, condition.Message)
+	status := instance.GetDriverStatus()
+	meta.SetStatusCondition(&status.Conditions, condition)
+	if condition.Status == metav1.ConditionTrue {
+		// Reset the hash to force a sync of the restored spec
+		status.DriverHash = 0
+	}
+	err = r.GetClient().Status().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update CR status")
+		return true, err
+	}
+	return true, nil
+}
+
+// getDriverRevision - Returns a specific revision from the revision history
+func getDriverRevision(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, revision int64) (*DriverRevision, error) {
+	revisions, err := getDriverRevisions(ctx, instance, r)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found in the revision history", revision)
+}
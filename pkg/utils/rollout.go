@@ -0,0 +1,240 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Container waiting reasons which indicate that a node pod has failed
+var failedContainerReasons = []string{"CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull",
+	"CreateContainerConfigError", "InvalidImageName"}
+
+// isVolumeAwareRollout - Returns true if the node pods are restarted by the operator
+func isVolumeAwareRollout(instance csiv1.CSIDriver) bool {
+	strategy := instance.GetDriver().Node.UpdateStrategy
+	return strategy != nil && strategy.Type == csiv1.NodeUpdateStrategyVolumeAware
+}
+
+// progressNodeRollout - Restarts the next batch of outdated node pods for the VolumeAware update strategy
+// Node pods on nodes with fewer volume attachments for the driver are restarted first.
+// The rollout is halted on the first failed node pod.
+// Returns the interval after which the rollout should be checked again (0 if there is nothing left to do)
+func progressNodeRollout(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config,
+	newStatus *csiv1.DriverStatus, reqLogger logr.Logger) time.Duration {
+	if !isVolumeAwareRollout(instance) {
+		newStatus.NodeRollout = nil
+		return 0
+	}
+	strategy := instance.GetDriver().Node.UpdateStrategy
+	ds := &appsv1.DaemonSet{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: instance.GetDaemonSetName(),
+		Namespace: instance.GetNamespace()}, ds)
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the node daemonset")
+		return constants.DefaultRetryInterval
+	}
+	checksum := ds.Spec.Template.Annotations[constants.PodTemplateChecksumKey]
+	rollout := newStatus.NodeRollout
+	if rollout == nil || rollout.TemplateChecksum != checksum {
+		rollout = &csiv1.NodeRolloutStatus{
+			State:            csiv1.NodeRolloutInProgress,
+			TemplateChecksum: checksum,
+		}
+	} else {
+		rollout = rollout.DeepCopy()
+	}
+	newStatus.NodeRollout = rollout
+	if rollout.State == csiv1.NodeRolloutHalted {
+		// Wait for the spec to be fixed
+		return 0
+	}
+
+	podList := &corev1.PodList{}
+	err = r.GetClient().List(ctx, podList, client.InNamespace(instance.GetNamespace()),
+		client.MatchingLabels{"app": instance.GetDaemonSetName()})
+	if err != nil {
+		rollout.Message = fmt.Sprintf("failed to list the node pods: %s", err.Error())
+		return constants.DefaultRetryInterval
+	}
+	outdated := make([]corev1.Pod, 0)
+	pending := ds.Status.DesiredNumberScheduled > int32(len(podList.Items))
+	updated := int32(0)
+	for _, pod := range podList.Items {
+		pod := pod
+		if pod.DeletionTimestamp != nil {
+			pending = true
+			continue
+		}
+		if pod.Annotations[constants.PodTemplateChecksumKey] != checksum {
+			outdated = append(outdated, pod)
+			continue
+		}
+		updated++
+		if reason := getPodFailure(&pod); reason != "" {
+			rollout.State = csiv1.NodeRolloutHalted
+			rollout.Message = fmt.Sprintf("Rollout halted as node pod %s on node %s failed: %s. Update the driver spec to resume",
+				pod.Name, pod.Spec.NodeName, reason)
+			reqLogger.Info(rollout.Message)
+			recordEvent(r, instance, corev1.EventTypeWarning, constants.ReasonNodeRolloutHalted, rollout.Message)
+			return 0
+		}
+		if !podutil.IsPodReady(&pod) {
+			pending = true
+		}
+	}
+	// Node pods of the current batch which haven't been recreated yet
+	missing := 0
+	if rollout.LastBatchTime != nil && metav1.Now().Sub(rollout.LastBatchTime.Time) < constants.MaxRetryInterval {
+		nodes := make(map[string]bool)
+		for _, pod := range podList.Items {
+			nodes[pod.Spec.NodeName] = true
+		}
+		for _, node := range rollout.CurrentBatch {
+			if !nodes[node] {
+				missing++
+			}
+		}
+	}
+	if missing > 0 {
+		pending = true
+	}
+	rollout.UpdatedPods = updated
+	rollout.TotalPods = int32(len(podList.Items) + missing)
+	if len(outdated) == 0 && !pending {
+		if rollout.State != csiv1.NodeRolloutCompleted {
+			rollout.State = csiv1.NodeRolloutCompleted
+			rollout.CurrentBatch = nil
+			rollout.Message = "All node pods are up to date"
+			recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonNodeRolloutCompleted, rollout.Message)
+		}
+		return 0
+	}
+	rollout.State = csiv1.NodeRolloutInProgress
+	if pending {
+		reqLogger.Info("Waiting for the restarted node pods to be ready")
+		return constants.DefaultRetryInterval
+	}
+	pause := time.Duration(0)
+	if strategy.Pause != nil {
+		pause = strategy.Pause.Duration
+	}
+	if rollout.LastBatchTime != nil {
+		if remaining := pause - metav1.Now().Sub(rollout.LastBatchTime.Time); remaining > 0 {
+			reqLogger.Info(fmt.Sprintf("Waiting %v before restarting the next batch of node pods", remaining.Round(time.Second)))
+			return remaining
+		}
+	}
+
+	attachments, err := getVolumeAttachmentCounts(ctx, instance, r, driverConfig, reqLogger)
+	if err != nil {
+		rollout.Message = fmt.Sprintf("failed to list the volume attachments: %s", err.Error())
+		return constants.DefaultRetryInterval
+	}
+	sort.SliceStable(outdated, func(i, j int) bool {
+		countI, countJ := attachments[outdated[i].Spec.NodeName], attachments[outdated[j].Spec.NodeName]
+		if countI != countJ {
+			return countI < countJ
+		}
+		return outdated[i].Spec.NodeName < outdated[j].Spec.NodeName
+	})
+	batchSize := 1
+	if strategy.MaxUnavailable != nil {
+		batchSize, err = intstr.GetScaledValueFromIntOrPercent(strategy.MaxUnavailable, int(rollout.TotalPods), true)
+		if err != nil || batchSize < 1 {
+			batchSize = 1
+		}
+	}
+	if batchSize > len(outdated) {
+		batchSize = len(outdated)
+	}
+	batch := make([]string, 0, batchSize)
+	for _, pod := range outdated[:batchSize] {
+		pod := pod
+		reqLogger.Info(fmt.Sprintf("Deleting node pod %s on node %s (%d volume attachments)",
+			pod.Name, pod.Spec.NodeName, attachments[pod.Spec.NodeName]))
+		err = r.GetClient().Delete(ctx, &pod)
+		if err != nil && !k8serror.IsNotFound(err) {
+			rollout.Message = fmt.Sprintf("failed to delete node pod %s: %s", pod.Name, err.Error())
+			return constants.DefaultRetryInterval
+		}
+		batch = append(batch, pod.Spec.NodeName)
+	}
+	now := metav1.Now()
+	rollout.CurrentBatch = batch
+	rollout.LastBatchTime = &now
+	rollout.Message = fmt.Sprintf("Restarting node pods on nodes: %s", strings.Join(batch, ", "))
+	recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonNodeRolloutBatch, rollout.Message)
+	if pause > constants.DefaultRetryInterval {
+		return pause
+	}
+	return constants.DefaultRetryInterval
+}
+
+// getVolumeAttachmentCounts - Returns the number of volume attachments for the driver on each node
+func getVolumeAttachmentCounts(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI,
+	driverConfig *ctrlconfig.Config, reqLogger logr.Logger) (map[string]int, error) {
+	driverNames := []string{instance.GetDefaultDriverName()}
+	customDriverName := GetCustomDriverName(instance, driverConfig.ConfigVersion,
+		GetControllerEnv(instance, driverConfig), reqLogger)
+	if customDriverName != "" {
+		driverNames = append(driverNames, customDriverName)
+	}
+	attachmentList := &storagev1.VolumeAttachmentList{}
+	err := r.GetClient().List(ctx, attachmentList)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, attachment := range attachmentList.Items {
+		if isStringInSlice(attachment.Spec.Attacher, driverNames) {
+			counts[attachment.Spec.NodeName]++
+		}
+	}
+	return counts, nil
+}
+
+// getPodFailure - Returns the reason if the pod has failed
+func getPodFailure(pod *corev1.Pod) string {
+	if pod.Status.Phase == corev1.PodFailed {
+		return fmt.Sprintf("pod phase is %s", corev1.PodFailed)
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...),
+		pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && isStringInSlice(status.State.Waiting.Reason, failedContainerReasons) {
+			return fmt.Sprintf("container %s is in %s", status.Name, status.State.Waiting.Reason)
+		}
+	}
+	return ""
+}
@@ -27,6 +27,7 @@ import (
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources/daemonset"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -212,6 +213,7 @@ func calculateState(ctx context.Context, instance csiv1.CSIDriver, driverConfig
 		// Even if there is an error message, it is okay to overwrite that as all the pods are in running state
 		running = true
 	}
+	newStatus.Rollout = getRolloutStatus(ctx, instance, driverConfig, r, newStatus, running)
 	var err error
 	if statefulSetErr != nil {
 		if daemonSetErr != nil {
@@ -229,6 +231,90 @@ func calculateState(ctx context.Context, instance csiv1.CSIDriver, driverConfig
 	return running, err
 }
 
+// getRolloutStatus - Calculates the progress of the rollout of the driver spec from the status of the driver workloads
+func getRolloutStatus(ctx context.Context, instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config, r ReconcileCSI,
+	newStatus *csiv1.DriverStatus, running bool) *csiv1.RolloutStatus {
+	rollout := &csiv1.RolloutStatus{}
+	if newStatus.Rollout != nil {
+		rollout = newStatus.Rollout.DeepCopy()
+	}
+	if rollout.TargetDriverHash != newStatus.DriverHash || rollout.StartTime == nil {
+		// A new rollout has started
+		startTime := metav1.Now()
+		rollout.TargetDriverHash = newStatus.DriverHash
+		rollout.StartTime = &startTime
+	}
+	rollout.Controller = csiv1.ControllerRolloutStatus{}
+	if driverConfig.DriverConfig == nil || driverConfig.DriverConfig.ControllerHA {
+		controller := &appsv1.Deployment{}
+		err := r.GetClient().Get(ctx, types.NamespacedName{Name: instance.GetControllerName(),
+			Namespace: instance.GetNamespace()}, controller)
+		if err == nil {
+			rollout.Controller.Replicas = getInt32(controller.Spec.Replicas)
+			rollout.Controller.AvailableReplicas = controller.Status.AvailableReplicas
+			// The updated replica count is stale till the latest spec is observed
+			if controller.Status.ObservedGeneration >= controller.Generation {
+				rollout.Controller.UpdatedReplicas = controller.Status.UpdatedReplicas
+			}
+		}
+	} else {
+		controller := &appsv1.StatefulSet{}
+		err := r.GetClient().Get(ctx, types.NamespacedName{Name: instance.GetControllerName(),
+			Namespace: instance.GetNamespace()}, controller)
+		if err == nil {
+			rollout.Controller.Replicas = getInt32(controller.Spec.Replicas)
+			rollout.Controller.AvailableReplicas = controller.Status.ReadyReplicas
+			if controller.Status.ObservedGeneration >= controller.Generation {
+				rollout.Controller.UpdatedReplicas = controller.Status.UpdatedReplicas
+			}
+		}
+	}
+	rollout.Node = getNodeRolloutStatus(ctx, instance.GetDaemonSetName(), instance.GetNamespace(), r)
+	// Only pods which are both updated & available count towards the progress
+	desired := rollout.Controller.Replicas + rollout.Node.DesiredNumberScheduled
+	updated := minInt32(rollout.Controller.UpdatedReplicas, rollout.Controller.AvailableReplicas) +
+		minInt32(rollout.Node.UpdatedNumberScheduled, rollout.Node.NumberAvailable)
+	rollout.Canary = nil
+	if canary := instance.GetDriver().Node.Canary; canary != nil && !canary.Promote {
+		canaryStatus := getNodeRolloutStatus(ctx, daemonset.GetCanaryName(instance.GetDaemonSetName()),
+			instance.GetNamespace(), r)
+		rollout.Canary = &canaryStatus
+		desired += canaryStatus.DesiredNumberScheduled
+		updated += minInt32(canaryStatus.UpdatedNumberScheduled, canaryStatus.NumberAvailable)
+	}
+	rollout.Progress = 0
+	if desired > 0 {
+		rollout.Progress = minInt32(updated*100/desired, 100)
+	}
+	if running && rollout.Progress == 100 {
+		rollout.CurrentDriverHash = rollout.TargetDriverHash
+	}
+	return rollout
+}
+
+// getNodeRolloutStatus - Returns the rollout status of a daemonset of the Node plugin
+func getNodeRolloutStatus(ctx context.Context, name, namespace string, r ReconcileCSI) csiv1.NodeWorkloadRolloutStatus {
+	status := csiv1.NodeWorkloadRolloutStatus{}
+	node := &appsv1.DaemonSet{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, node)
+	if err == nil {
+		status.DesiredNumberScheduled = node.Status.DesiredNumberScheduled
+		status.NumberAvailable = node.Status.NumberAvailable
+		// The updated pod count is stale till the latest spec is observed
+		if node.Status.ObservedGeneration >= node.Generation {
+			status.UpdatedNumberScheduled = node.Status.UpdatedNumberScheduled
+		}
+	}
+	return status
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 // CalculateProxyState - Calculates the state of the Reverse Proxy CR
 func CalculateProxyState(ctx context.Context, deploymentName, namespace string, client client.Client,
 	newStatus *csiv1.CSIPowerMaxRevProxyStatus) (bool, error) {
@@ -244,9 +330,15 @@ func CalculateProxyState(ctx context.Context, deploymentName, namespace string,
 
 // HashDriver returns the hash of the driver specification
 // This is used to detect if the driver spec has changed and any updates are required
+// The config version resolved from a channel alias is hashed so that the driver is updated when the channel moves
 func HashDriver(instance csiv1.CSIDriver) uint64 {
 	hash := fnv.New32a()
-	driverJSON, _ := json.Marshal(instance.GetDriver())
+	driver := instance.GetDriver()
+	if configVersion := GetConfigVersion(instance); configVersion != driver.ConfigVersion {
+		driver = driver.DeepCopy()
+		driver.ConfigVersion = configVersion
+	}
+	driverJSON, _ := json.Marshal(driver)
 	hashutil.DeepHashObject(hash, driverJSON)
 	return uint64(hash.Sum32())
 }
@@ -268,6 +360,17 @@ func setStatus(instance csiv1.CSIDriver, newStatus *csiv1.DriverStatus) {
 	instance.GetDriverStatus().ControllerStatus = newStatus.ControllerStatus
 	instance.GetDriverStatus().NodeStatus = newStatus.NodeStatus
 	instance.GetDriverStatus().DriverHash = newStatus.DriverHash
+	instance.GetDriverStatus().AvailableUpgrades = newStatus.AvailableUpgrades
+	instance.GetDriverStatus().ResolvedConfigVersion = newStatus.ResolvedConfigVersion
+	instance.GetDriverStatus().MissingDependencies = newStatus.MissingDependencies
+	instance.GetDriverStatus().CertificateExpiry = newStatus.CertificateExpiry
+	instance.GetDriverStatus().StorageArrays = newStatus.StorageArrays
+	instance.GetDriverStatus().Conditions = newStatus.Conditions
+	instance.GetDriverStatus().Revision = newStatus.Revision
+	instance.GetDriverStatus().NodeRollout = newStatus.NodeRollout
+	instance.GetDriverStatus().PendingUpdate = newStatus.PendingUpdate
+	instance.GetDriverStatus().Rollout = newStatus.Rollout
+	instance.GetDriverStatus().RestartNonce = newStatus.RestartNonce
 }
 
 func setLastStatusUpdate(status *csiv1.DriverStatus, conditionType csiv1.CSIOperatorConditionType, errorMsg string) csiv1.LastUpdate {
@@ -319,6 +422,9 @@ func handleValidationError(ctx context.Context, instance csiv1.CSIDriver, driver
 	_, _ = calculateState(ctx, instance, driverConfig, r, newStatus)
 	newStatus.LastUpdate = setLastStatusUpdate(oldStatus, csiv1.InvalidConfig, validationError.Error())
 	newStatus.State = constants.InvalidConfig
+	newStatus.MissingDependencies = getMissingDependencies(validationError)
+	// The storage arrays of an invalid config aren't reported
+	newStatus.StorageArrays = nil
 	_ = updateStatus(ctx, instance, r, reqLogger, newStatus, oldStatus)
 	reqLogger.Error(validationError, fmt.Sprintf("*************Create/Update %s failed ********",
 		instance.GetDriverType()))
@@ -344,12 +450,29 @@ func GetOperatorConditionTypeFromState(state csiv1.DriverState) csiv1.CSIOperato
 
 func handleSuccess(ctx context.Context, instance csiv1.CSIDriver, driverConfig *ctrlconfig.Config, r ReconcileCSI, reqLogger logr.Logger, newStatus, oldStatus *csiv1.DriverStatus) (reconcile.Result, error) {
 	errorMsg := ""
+	// Keep the copies of the secrets referenced from other namespaces up to date
+	if err := mirrorDriverSecrets(ctx, instance, r, reqLogger); err != nil {
+		reqLogger.Error(err, "Failed to mirror the secrets referenced from other namespaces")
+	}
+	// The config secret may have been edited without any change to the driver spec
+	if err := validateStorageArrays(ctx, instance, r, newStatus, reqLogger); err != nil {
+		reqLogger.Error(err, "Invalid storage arrays in the config secret of the driver")
+		newStatus.StorageArrays = nil
+	}
 	running, err := calculateState(ctx, instance, driverConfig, r, newStatus)
 	if err != nil {
 		errorMsg = err.Error()
 	}
+	rolloutInterval := progressNodeRollout(ctx, instance, r, driverConfig, newStatus, reqLogger)
+	if rolloutInterval != 0 {
+		// Node pods are still being restarted
+		running = false
+	}
 	if running {
 		newStatus.State = constants.Running
+		if oldStatus.State != constants.Running {
+			recordDriverRevision(ctx, instance, r, driverConfig, newStatus, reqLogger)
+		}
 	} else if err != nil {
 		newStatus.State = constants.Updating
 	} else {
@@ -357,7 +480,9 @@ func handleSuccess(ctx context.Context, instance csiv1.CSIDriver, driverConfig *
 	}
 	newStatus.LastUpdate = setLastStatusUpdate(oldStatus,
 		GetOperatorConditionTypeFromState(newStatus.State), errorMsg)
-	retryInterval := constants.DefaultRetryInterval
+	certCheckInterval := checkDriverCertificateExpiry(ctx, instance, r, driverConfig, newStatus, reqLogger)
+	retry := getRetryPolicy(instance, r)
+	retryInterval := retry.interval
 	requeue := true
 	if newStatus.State == constants.Running {
 		// If previously we were in running state
@@ -372,21 +497,31 @@ func handleSuccess(ctx context.Context, instance csiv1.CSIDriver, driverConfig *
 		} else if oldStatus.State == constants.Succeeded {
 			timeSinceLastConditionChange := metav1.Now().Sub(oldStatus.LastUpdate.Time.Time).Round(time.Millisecond)
 			reqLogger.Info(fmt.Sprintf("Time since last condition change: %v", timeSinceLastConditionChange))
-			if timeSinceLastConditionChange >= constants.MaxRetryDuration {
+			if timeSinceLastConditionChange >= retry.maxDuration {
 				// Don't requeue again
 				requeue = false
 				reqLogger.Info("Time elapsed since last condition change is more than max limit. Not going to reconcile")
 			} else {
-				// set to the default retry interval at minimum
+				// set to the retry interval at minimum
 				retryInterval = time.Duration(math.Max(float64(timeSinceLastConditionChange.Nanoseconds()*2),
-					float64(constants.DefaultRetryInterval)))
-				// Maximum set to MaxRetryInterval
-				retryInterval = time.Duration(math.Min(float64(retryInterval), float64(constants.MaxRetryInterval)))
+					float64(retry.interval)))
+				// Maximum set to the max retry interval
+				retryInterval = time.Duration(math.Min(float64(retryInterval), float64(retry.maxInterval)))
 			}
 		}
 	} else {
 		requeue = true
 	}
+	if rolloutInterval != 0 {
+		// Keep requeuing till the rollout of the node pods is complete
+		requeue = true
+		retryInterval = rolloutInterval
+	}
+	if !requeue && certCheckInterval != 0 {
+		// Check the expiry of the certificates again
+		requeue = true
+		retryInterval = certCheckInterval
+	}
 	updateStatusError := updateStatus(ctx, instance, r, reqLogger, newStatus, oldStatus)
 	if updateStatusError != nil {
 		reqLogger.Error(updateStatusError, "failed to update the status")
@@ -0,0 +1,44 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/go-logr/logr"
+)
+
+// StorageArrayValidator - Implemented by the reconcilers of the drivers which read their storage arrays from a
+// config secret. The config secret is read & validated once per reconcile and the storage arrays it configures
+// are reported in the status of the driver
+type StorageArrayValidator interface {
+	ValidateStorageArrays(ctx context.Context, instance csiv1.CSIDriver, reqLogger logr.Logger) ([]csiv1.StorageArrayStatus, error)
+}
+
+// validateStorageArrays - Validates the config secret of the driver & records its storage arrays in the new status
+func validateStorageArrays(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, newStatus *csiv1.DriverStatus,
+	reqLogger logr.Logger) error {
+	validator, ok := r.(StorageArrayValidator)
+	if !ok {
+		return nil
+	}
+	arrays, err := validator.ValidateStorageArrays(ctx, instance, reqLogger)
+	if err != nil {
+		return err
+	}
+	newStatus.StorageArrays = arrays
+	return nil
+}
@@ -0,0 +1,241 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/resources/secrets"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TrustedCABundleInjectionLabel - Label of the ConfigMaps in which OpenShift injects its trusted CA bundle
+	TrustedCABundleInjectionLabel = "config.openshift.io/inject-trusted-cabundle"
+	// TrustedCABundleKey - Key of the ConfigMaps in which OpenShift injects its trusted CA bundle
+	TrustedCABundleKey = "ca-bundle.crt"
+)
+
+// GetTrustBundleSecretName - Returns the name of the Secret in which the trust bundle of the driver is converted
+func GetTrustBundleSecretName(instance csiv1.CSIDriver) string {
+	return fmt.Sprintf("%s-trust-bundle", instance.GetName())
+}
+
+// ValidateTrustBundle - Validates the reference to the trust bundle
+func ValidateTrustBundle(bundle *csiv1.TrustBundle) error {
+	if bundle == nil {
+		return nil
+	}
+	if (bundle.ConfigMapKeyRef == nil) == (bundle.SecretKeyRef == nil) {
+		return fmt.Errorf("invalid trustBundle: exactly one of configMapKeyRef & secretKeyRef must be specified")
+	}
+	if ref := bundle.ConfigMapKeyRef; ref != nil && (ref.Name == "" || ref.Key == "") {
+		return fmt.Errorf("invalid trustBundle: name & key of configMapKeyRef must be specified")
+	}
+	if ref := bundle.SecretKeyRef; ref != nil && (ref.Name == "" || ref.Key == "") {
+		return fmt.Errorf("invalid trustBundle: name & key of secretKeyRef must be specified")
+	}
+	if bundle.InjectTrustedCABundle && (bundle.ConfigMapKeyRef == nil || bundle.ConfigMapKeyRef.Key != TrustedCABundleKey) {
+		return fmt.Errorf("invalid trustBundle: injectTrustedCABundle requires the key %s of a configMapKeyRef",
+			TrustedCABundleKey)
+	}
+	return nil
+}
+
+// SyncTrustedCABundleConfigMap - Creates the ConfigMap in which OpenShift injects its trusted CA bundle if the
+// trust bundle requests it. An existing ConfigMap is labeled for the injection. Its data is left to OpenShift
+func SyncTrustedCABundleConfigMap(ctx context.Context, bundle *csiv1.TrustBundle, owner metav1.OwnerReference,
+	namespace string, client crclient.Client, reqLogger logr.Logger) error {
+	if bundle == nil || !bundle.InjectTrustedCABundle || ValidateTrustBundle(bundle) != nil {
+		return nil
+	}
+	name := bundle.ConfigMapKeyRef.Name
+	found := &corev1.ConfigMap{}
+	err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, found)
+	if k8serror.IsNotFound(err) {
+		reqLogger.Info("Creating the ConfigMap for the trusted CA bundle", "Name", name)
+		return client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       namespace,
+				Labels:          map[string]string{TrustedCABundleInjectionLabel: "true"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+		})
+	} else if err != nil {
+		return err
+	}
+	if found.Labels[TrustedCABundleInjectionLabel] == "true" {
+		return nil
+	}
+	if found.Labels == nil {
+		found.Labels = make(map[string]string)
+	}
+	found.Labels[TrustedCABundleInjectionLabel] = "true"
+	reqLogger.Info("Labeling the ConfigMap for the injection of the trusted CA bundle", "Name", name)
+	return client.Update(ctx, found)
+}
+
+// GetTrustBundleChecksum - Returns a checksum of the contents of the trust bundle
+// Returns an empty string if there is no trust bundle
+func GetTrustBundleChecksum(ctx context.Context, bundle *csiv1.TrustBundle, namespace string,
+	client crclient.Client) (string, error) {
+	if bundle == nil {
+		return "", nil
+	}
+	data, err := GetTrustBundle(ctx, bundle, namespace, client)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// GetTrustBundle - Returns the contents of the trust bundle
+// Returns a MissingDependencyError if the referenced ConfigMap or Secret doesn't exist
+func GetTrustBundle(ctx context.Context, bundle *csiv1.TrustBundle, namespace string, client crclient.Client) ([]byte, error) {
+	var data []byte
+	if ref := bundle.ConfigMapKeyRef; ref != nil {
+		configMap := &corev1.ConfigMap{}
+		err := client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, configMap)
+		if k8serror.IsNotFound(err) {
+			if ref.Optional != nil && *ref.Optional {
+				return nil, nil
+			}
+			return nil, &MissingDependencyError{Kind: DependencyKindConfigMap, Name: ref.Name,
+				Err: fmt.Errorf("failed to find trust bundle configmap %s", ref.Name)}
+		} else if err != nil {
+			return nil, err
+		}
+		data = []byte(configMap.Data[ref.Key])
+		if binary, ok := configMap.BinaryData[ref.Key]; ok {
+			data = binary
+		}
+	} else {
+		ref := bundle.SecretKeyRef
+		name := LocalSecretName(ref.Name)
+		secret := &corev1.Secret{}
+		err := client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+		if k8serror.IsNotFound(err) {
+			if ref.Optional != nil && *ref.Optional {
+				return nil, nil
+			}
+			return nil, NewMissingSecretError(name, fmt.Errorf("failed to find trust bundle secret %s", name))
+		} else if err != nil {
+			return nil, err
+		}
+		data = secret.Data[ref.Key]
+	}
+	return data, nil
+}
+
+// splitTrustBundle - Returns each of the valid PEM encoded certificates of the bundle separately
+func splitTrustBundle(data []byte) [][]byte {
+	certs := make([][]byte, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			continue
+		}
+		certs = append(certs, pem.EncodeToMemory(block))
+	}
+}
+
+// syncTrustBundleSecret - Converts the trust bundle of the driver to a Secret with a cert-<n> key per certificate,
+// which is the layout of the certificate secrets expected by the drivers. Returns nil if the driver spec doesn't
+// have a trust bundle or if the bundle is empty
+func syncTrustBundleSecret(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client,
+	reqLogger logr.Logger) (*certSecret, error) {
+	bundle := instance.GetDriver().TrustBundle
+	if bundle == nil {
+		return nil, nil
+	}
+	data, err := GetTrustBundle(ctx, bundle, instance.GetNamespace(), client)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		reqLogger.Info("Warning: The trust bundle is empty")
+		return nil, nil
+	}
+	certs := splitTrustBundle(data)
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("trust bundle doesn't hold a PEM encoded certificate")
+	}
+	secret := secrets.New(instance, GetTrustBundleSecretName(instance))
+	keys := make([]string, 0, len(certs))
+	for i, cert := range certs {
+		key := fmt.Sprintf("cert-%d", i)
+		secret.Data[key] = cert
+		keys = append(keys, key)
+	}
+	found := &corev1.Secret{}
+	err = client.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
+	if err == nil {
+		if !metav1.IsControlledBy(found, instance) {
+			return nil, fmt.Errorf("secret %s already exists & isn't managed by the driver", secret.Name)
+		}
+		if !reflect.DeepEqual(found.Data, secret.Data) {
+			err = secrets.SyncSecret(ctx, secret, client, reqLogger)
+		}
+	} else if k8serror.IsNotFound(err) {
+		err = secrets.SyncSecret(ctx, secret, client, reqLogger)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &certSecret{name: secret.Name, keys: keys}, nil
+}
+
+// validateTrustBundle - Validates the trust bundle in the driver spec
+func validateTrustBundle(ctx context.Context, instance csiv1.CSIDriver, client crclient.Client, log logr.Logger) error {
+	bundle := instance.GetDriver().TrustBundle
+	if bundle == nil {
+		return nil
+	}
+	err := ValidateTrustBundle(bundle)
+	if err != nil {
+		return err
+	}
+	data, err := GetTrustBundle(ctx, bundle, instance.GetNamespace(), client)
+	if err != nil {
+		if getMissingDependencies(err) != nil {
+			return err
+		}
+		log.Error(err, "Failed to query for the trust bundle. Warning - the driver pods may not start")
+		return nil
+	}
+	if len(data) != 0 && !isPEMCertificate(data) {
+		return fmt.Errorf("trust bundle doesn't hold a PEM encoded certificate")
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	csiv1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/resources/rbac"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyUpgradePolicy - Computes the config versions available for an upgrade and,
+// if an automatic upgrade policy is set, moves a Running driver one step forward.
+// Returns the available upgrades and a boolean which indicates if the driver was upgraded
+func applyUpgradePolicy(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, opConfig *ctrlconfig.OpConfig,
+	reqLogger logr.Logger) ([]string, bool, error) {
+	driver := instance.GetDriver()
+	policy := driver.UpgradePolicy
+	switch policy {
+	case "", csiv1.UpgradePolicyManual, csiv1.UpgradePolicyAutoPatch, csiv1.UpgradePolicyAutoMinor:
+	default:
+		return nil, false, fmt.Errorf("invalid upgrade policy: %s. Valid values are %s, %s & %s", policy,
+			csiv1.UpgradePolicyManual, csiv1.UpgradePolicyAutoPatch, csiv1.UpgradePolicyAutoMinor)
+	}
+	configVersion := GetConfigVersion(instance)
+	availableUpgrades := opConfig.GetAvailableUpgrades(instance.GetDriverType(), configVersion,
+		r.GetConfig().KubeAPIServerVersion)
+	if policy == "" || policy == csiv1.UpgradePolicyManual {
+		return availableUpgrades, false, nil
+	}
+	// Drivers which follow a channel are moved by the channel
+	if configVersion != driver.ConfigVersion {
+		reqLogger.Info(fmt.Sprintf("Ignoring %s upgrade policy as the config version follows the channel %s",
+			policy, driver.ConfigVersion))
+		return availableUpgrades, false, nil
+	}
+	// Only move drivers which are known to be working
+	if instance.GetDriverStatus().State != constants.Running {
+		return availableUpgrades, false, nil
+	}
+	// Only consider the upgrades allowed by the upgrade paths
+	allowedUpgrades := make([]string, 0)
+	for _, upgrade := range availableUpgrades {
+		if opConfig.IsUpgradeAllowed(instance.GetDriverType(), driver.ConfigVersion, upgrade) == nil {
+			allowedUpgrades = append(allowedUpgrades, upgrade)
+		}
+	}
+	nextVersion := ctrlconfig.GetNextUpgrade(driver.ConfigVersion, allowedUpgrades, policy)
+	if nextVersion == "" {
+		reqLogger.Info(fmt.Sprintf("No upgrades allowed by %s upgrade policy", policy))
+		return availableUpgrades, false, nil
+	}
+	previousVersion := driver.ConfigVersion
+	driver.ConfigVersion = nextVersion
+	err := r.GetClient().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the config version")
+		return availableUpgrades, false, err
+	}
+	message := fmt.Sprintf("Upgraded config version from %s to %s as per %s upgrade policy",
+		previousVersion, nextVersion, policy)
+	reqLogger.Info(message)
+	recordEvent(r, instance, corev1.EventTypeNormal, constants.ReasonConfigVersionUpgraded, message)
+	status := instance.GetDriverStatus()
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               constants.ConditionAutoUpgrade,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: instance.GetGeneration(),
+		Reason:             constants.ReasonConfigVersionUpgraded,
+		Message:            message,
+	})
+	status.AvailableUpgrades = opConfig.GetAvailableUpgrades(instance.GetDriverType(), nextVersion,
+		r.GetConfig().KubeAPIServerVersion)
+	err = r.GetClient().Status().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update CR status")
+	}
+	return status.AvailableUpgrades, true, nil
+}
+
+// validateConfigVersionChange - Validates the change of config version (if any) against the
+// upgrade paths declared in the operator config. The check can be skipped once via an override annotation
+func validateConfigVersionChange(instance csiv1.CSIDriver, opConfig *ctrlconfig.OpConfig, reqLogger logr.Logger) error {
+	annotations := instance.GetAnnotations()
+	previousVersion, ok := annotations[configVersionKey]
+	if !ok || previousVersion == "" {
+		return nil
+	}
+	err := opConfig.IsUpgradeAllowed(instance.GetDriverType(), previousVersion, GetConfigVersion(instance))
+	if err == nil {
+		return nil
+	}
+	if annotations[upgradePathOverrideKey] == "true" {
+		reqLogger.Info(fmt.Sprintf("Warning: %s. Continuing as %s is set", err.Error(), upgradePathOverrideKey))
+		// The override only applies to this config version change. The annotation is removed along with
+		// the update of the config version annotation
+		delete(annotations, upgradePathOverrideKey)
+		instance.SetAnnotations(annotations)
+		return nil
+	}
+	return fmt.Errorf("%s. Set the annotation %s to \"true\" to override", err.Error(), upgradePathOverrideKey)
+}
+
+// reportUpgradeCompatibility - Records the differences between the driver configs of the previous & the new
+// config version (if changed) as a condition in the driver status
+func reportUpgradeCompatibility(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI,
+	driverConfig *ctrlconfig.Config, reqLogger logr.Logger) error {
+	previousVersion := instance.GetAnnotations()[configVersionKey]
+	configVersion := driverConfig.ConfigVersion
+	if previousVersion == "" || previousVersion == configVersion {
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:               constants.ConditionUpgradeCompatibility,
+		ObservedGeneration: instance.GetGeneration(),
+	}
+	k8sVersion := r.GetConfig().KubeAPIServerVersion
+	previousConfig, previousK8sVersion, err := ctrlconfig.ReadDriverConfig(r.GetConfig().ConfigDirectory,
+		instance.GetDriverType(), previousVersion, k8sVersion)
+	if err != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = constants.ReasonCompatibilityUnknown
+		condition.Message = fmt.Sprintf("Failed to read the driver config of %s: %s", previousVersion, err.Error())
+	} else {
+		report := ctrlconfig.CompareDriverConfigs(previousVersion, previousConfig, configVersion, driverConfig.DriverConfig)
+		if previousK8sVersion != k8sVersion {
+			report.FromK8sVersion = previousK8sVersion
+		}
+		previousController, previousNode := getClusterRoleRules(instance, previousVersion, previousConfig)
+		controller, node := getClusterRoleRules(instance, configVersion, driverConfig.DriverConfig)
+		report.CompareClusterRoles("controller", previousController, controller)
+		report.CompareClusterRoles("node", previousNode, node)
+		report.CheckDriverSpec(instance.GetDriver())
+		condition.Message = report.String()
+		if report.IsCompatible() {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = constants.ReasonUpgradeCompatible
+		} else {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = constants.ReasonUpgradeIncompatible
+		}
+	}
+	reqLogger.Info(condition.Message)
+	status := instance.GetDriverStatus()
+	meta.SetStatusCondition(&status.Conditions, condition)
+	err = r.GetClient().Status().Update(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update CR status")
+	}
+	return err
+}
+
+// getClusterRoleRules - Returns the rules of the cluster roles of the controller & the node plugin
+// for a config version
+func getClusterRoleRules(instance csiv1.CSIDriver, configVersion string,
+	driverConfig *ctrlconfig.DriverConfig) ([]rbacv1.PolicyRule, []rbacv1.PolicyRule) {
+	dummyClusterRole := rbac.NewDummyClusterRole(getDummyClusterRoleName(instance))
+	controller := rbac.NewControllerClusterRole(instance, false, driverConfig.ControllerHA, dummyClusterRole)
+	node := rbac.NewNodeClusterRole(instance, false, dummyClusterRole)
+	if IsLimitedNodeRBAC(instance.GetDriverType(), configVersion) {
+		node = rbac.NewLimitedClusterRole(instance, false, dummyClusterRole)
+	}
+	return controller.Rules, node.Rules
+}
@@ -18,10 +18,12 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -150,6 +152,19 @@ func driverChanged(instance csiv1.CSIDriver) (uint64, uint64, bool) {
 	return expectedHash, instance.GetDriverStatus().DriverHash, instance.GetDriverStatus().DriverHash != expectedHash
 }
 
+// applyForceUpdate - Maps the deprecated ForceUpdate flag to a new RestartNonce
+// Returns true if a force update was requested
+func applyForceUpdate(instance csiv1.CSIDriver, reqLogger logr.Logger) bool {
+	driver := instance.GetDriver()
+	if !driver.ForceUpdate {
+		return false
+	}
+	reqLogger.Info("Warning: forceUpdate is deprecated. Use restartNonce instead")
+	driver.ForceUpdate = false
+	driver.RestartNonce = metav1.Now().UTC().Format(time.RFC3339)
+	return true
+}
+
 // ProxyChanged - Checks if proxy spec has changed
 func ProxyChanged(instance *csiv1.CSIPowerMaxRevProxy) (uint64, uint64, bool) {
 	expectedHash := HashProxy(instance)
@@ -194,6 +209,23 @@ func isStringInSlice(str string, slice []string) bool {
 	return false
 }
 
+// recordEvent - Records an event for the driver instance if an event recorder is available
+func recordEvent(r ReconcileCSI, instance csiv1.CSIDriver, eventType, reason, message string) {
+	if recorder := r.GetEventRecorder(); recorder != nil {
+		recorder.Event(instance, eventType, reason, message)
+	}
+}
+
+// getImageTag - Returns the tag portion of an image reference
+func getImageTag(image string) string {
+	image = strings.Split(image, "@")[0]
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		return name[i+1:]
+	}
+	return ""
+}
+
 func getEnvVar(name string, envs []corev1.EnvVar) (corev1.EnvVar, error) {
 	for _, env := range envs {
 		if env.Name == name {
@@ -21,6 +21,7 @@ import (
 
 	csiv1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/resources/daemonset"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -36,47 +37,89 @@ func ValidateSpec(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI,
 	if common.Image == "" {
 		return fmt.Errorf("driver image not specified in spec")
 	}
+	// The missing dependencies are collected so that all of them are reported at once
+	missing := &missingDependencies{}
 	// Check is the credentials secret exists for controller
 	err := checkIfCredentialsSecretExists(ctx, instance, r, driverConfig, "controller", log)
-	if err != nil {
-		return err
+	if err != nil && !missing.add(err) {
+		return missing.join(err)
 	}
 	combinedControllerEnvs := mergeEnvironmentVars(common.Envs, controller.Envs)
 	err = validateUserEnv(driverConfig, combinedControllerEnvs, "controller")
 	if err != nil {
-		return err
+		return missing.join(err)
 	}
 	// Check for controller secret
 	if isCertificateValidationRequested(combinedControllerEnvs, string(instance.GetDriverType())) {
 		err = checkCertSecret(ctx, instance, r, driverConfig, "controller", log)
-		if err != nil {
-			return err
+		if err != nil && !missing.add(err) {
+			return missing.join(err)
 		}
 	}
+	// Check the certificate secrets declared in the spec
+	err = validateCertSecrets(ctx, instance, r.GetClient(), log)
+	if err != nil && !missing.add(err) {
+		return missing.join(err)
+	}
+	// Check the trust bundle
+	err = validateTrustBundle(ctx, instance, r.GetClient(), log)
+	if err != nil && !missing.add(err) {
+		return missing.join(err)
+	}
+	// Check the CHAP settings
+	err = validateCHAP(instance)
+	if err != nil {
+		return missing.join(err)
+	}
 	// Check is the credentials secret exists for node
 	err = checkIfCredentialsSecretExists(ctx, instance, r, driverConfig, "node", log)
-	if err != nil {
-		return err
+	if err != nil && !missing.add(err) {
+		return missing.join(err)
 	}
 	combinedNodeEnvs := mergeEnvironmentVars(common.Envs, node.Envs)
 	err = validateUserEnv(driverConfig, combinedNodeEnvs, "node")
 	if err != nil {
-		return err
+		return missing.join(err)
 	}
 	// Check for node secret
 	if isCertificateValidationRequested(combinedNodeEnvs, string(instance.GetDriverType())) {
 		err = checkCertSecret(ctx, instance, r, driverConfig, "node", log)
-		if err != nil {
-			return err
+		if err != nil && !missing.add(err) {
+			return missing.join(err)
 		}
 	}
+	// Check the update strategy for node
+	err = daemonset.ValidateUpdateStrategy(node.UpdateStrategy)
+	if err != nil {
+		return missing.join(fmt.Errorf("invalid node update strategy: %v", err))
+	}
+	// Check the maintenance windows
+	err = validateMaintenanceWindows(instance.GetDriver().MaintenanceWindows)
+	if err != nil {
+		return missing.join(err)
+	}
+	// Check the retry policy
+	err = validateRetryPolicy(instance.GetDriver().RetryPolicy)
+	if err != nil {
+		return missing.join(err)
+	}
+	// Check the certificate expiry thresholds
+	err = ValidateCertExpiryThresholds(instance.GetDriver().CertExpiryThresholds)
+	if err != nil {
+		return missing.join(err)
+	}
+	// Check the canary for node
+	err = validateNodeCanary(instance, r, driverConfig)
+	if err != nil {
+		return missing.join(err)
+	}
 	if len(instance.GetDriver().StorageClass) > 0 {
 		log.Info("Warning: Creation of storage class via operator is deprecated")
 	}
 	if len(instance.GetDriver().SnapshotClass) > 0 {
 		log.Info("Warning: Creation of snapshot class via operator is deprecated")
 	}
-	return nil
+	return missing.join(nil)
 }
 
 func checkIfCredentialsSecretExists(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI,
@@ -120,14 +163,15 @@ func checkIfCredentialsSecretExists(ctx context.Context, instance csiv1.CSIDrive
 	log.Info(fmt.Sprintf("Default secret name: %s", credentialsSecretName))
 	// The user provided secret takes priority over the default one
 	if driver.AuthSecret != "" {
-		credentialsSecretName = driver.AuthSecret
+		credentialsSecretName = LocalSecretName(driver.AuthSecret)
 		log.Info(fmt.Sprintf("User specified secret name: %s", credentialsSecretName))
 	}
 	found := &corev1.Secret{}
 	err = r.GetClient().Get(ctx, types.NamespacedName{Name: credentialsSecretName,
 		Namespace: instance.GetNamespace()}, found)
 	if err != nil && errors.IsNotFound(err) {
-		return fmt.Errorf("failed to find secret: [%s] for connecting to the API endpoint", credentialsSecretName)
+		return NewMissingSecretError(credentialsSecretName,
+			fmt.Errorf("failed to find secret: [%s] for connecting to the API endpoint", credentialsSecretName))
 	} else if err != nil {
 		log.Error(err, "Failed to query for secret. Warning - the driver pod may not start")
 	} else {
@@ -147,7 +191,8 @@ func checkIfCredentialsSecretExists(ctx context.Context, instance csiv1.CSIDrive
 				iscsiCHAPEnvName := "X_CSI_POWERMAX_ISCSI_ENABLE_CHAP"
 				iscsiCHAPEnv, err := getEnvVar(iscsiCHAPEnvName, mergedEnvs)
 				if err == nil {
-					if strings.Compare(strings.ToUpper(iscsiCHAPEnv.Value), "TRUE") == 0 {
+					// The CHAP secret isn't read from the credentials secret if it is generated by the operator
+					if strings.Compare(strings.ToUpper(iscsiCHAPEnv.Value), "TRUE") == 0 && !isCHAPSecretGenerated(instance) {
 						if _, found := secretData["chapsecret"]; !found {
 							return fmt.Errorf("chapsecret key not found in secret")
 						}
@@ -182,9 +227,17 @@ func validateUserEnv(driverConfig *ctrlconfig.Config, userSpecifiedEnvs []corev1
 func checkCertSecret(ctx context.Context, instance csiv1.CSIDriver, r ReconcileCSI, driverConfig *ctrlconfig.Config,
 	driverContainerType string, log logr.Logger) error {
 	certVolume := instance.GetCertVolumeName()
-	if certVolume == "" {
+	if certVolume == "" || hasCertSecrets(instance) || instance.GetDriver().TrustBundle != nil {
+		// The certificate secrets & the trust bundle declared in the driver spec are validated separately
 		return nil
 	}
+	if instance.GetDriverType() == csiv1.Unity || instance.GetDriverType() == csiv1.Isilon {
+		// The certificates can be placed in the numbered certificate secrets instead
+		numbered, err := getNumberedCertSecrets(ctx, instance, r.GetClient(), log)
+		if err == nil && len(numbered) != 0 {
+			return nil
+		}
+	}
 	volumes := make([]corev1.Volume, 0)
 	if driverContainerType == "controller" {
 		volumes = driverConfig.GetControllerVolumes()
@@ -210,7 +263,8 @@ func checkCertSecret(ctx context.Context, instance csiv1.CSIDriver, r ReconcileC
 	err := r.GetClient().Get(ctx, types.NamespacedName{Name: certName,
 		Namespace: instance.GetNamespace()}, found)
 	if err != nil && errors.IsNotFound(err) {
-		return fmt.Errorf("failed to find secret %s and certificate validation is requested", certName)
+		return NewMissingSecretError(certName,
+			fmt.Errorf("failed to find secret %s and certificate validation is requested", certName))
 	} else if err != nil {
 		log.Error(err, "Failed to query for secret. Warning - the controller pod may not start")
 	}
@@ -219,14 +273,10 @@ func checkCertSecret(ctx context.Context, instance csiv1.CSIDriver, r ReconcileC
 
 // isCertificateValidationRequested - Checks if validation of certificate is requested
 func isCertificateValidationRequested(envs []corev1.EnvVar, driverName string) bool {
-	envName := fmt.Sprintf("X_CSI_%s", strings.ToUpper(driverName))
-	envNameSuffix := ""
-	if driverName == "powermax" {
-		envNameSuffix = "SKIP_CERTIFICATE_VALIDATION"
-	} else {
-		envNameSuffix = "INSECURE"
+	envName, ok := skipCertValidationEnvs[csiv1.DriverType(driverName)]
+	if !ok {
+		envName = fmt.Sprintf("X_CSI_%s_INSECURE", strings.ToUpper(driverName))
 	}
-	envName = fmt.Sprintf("%s_%s", envName, envNameSuffix)
 	envValue, err := getEnvValue(envName, envs)
 	if err != nil {
 		return false
@@ -0,0 +1,266 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// newTestCertificate - Returns a PEM encoded self-signed certificate expiring at notAfter
+func newTestCertificate(t *testing.T, commonName string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newCertSecret - Returns a secret in the Isilon test namespace holding the data
+func newCertSecret(name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-isilon"},
+		Data:       data,
+	}
+}
+
+// getCertificateExpiryMetric - Returns the value of the certificate expiry gauge for the CR (if exported)
+func getCertificateExpiryMetric(t *testing.T, name string) (float64, bool) {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "dell_csi_operator_certificate_expiry_timestamp_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == name {
+					return metric.GetGauge().GetValue(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// drainEvents - Returns the events recorded so far
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := make([]string, 0)
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name              string
+		expiresIn         time.Duration
+		thresholds        []metav1.Duration
+		reported          *metav1.Duration
+		expectedReason    string
+		expectedThreshold *time.Duration
+		expectedNextCheck time.Duration
+		expectedEvent     bool
+	}{
+		{name: "valid", expiresIn: 60 * day, expectedReason: constants.ReasonCertificatesValid,
+			expectedNextCheck: constants.CertExpiryCheckInterval},
+		{name: "valid until the next check", expiresIn: 30*day + 2*time.Hour,
+			expectedReason: constants.ReasonCertificatesValid, expectedNextCheck: 2 * time.Hour},
+		{name: "within 30 days", expiresIn: 20 * day, expectedReason: constants.ReasonCertificateExpiring,
+			expectedThreshold: durationPtr(30 * day), expectedNextCheck: constants.CertExpiryCheckInterval,
+			expectedEvent: true},
+		{name: "within 7 days", expiresIn: 5 * day, expectedReason: constants.ReasonCertificateExpiring,
+			expectedThreshold: durationPtr(7 * day), expectedNextCheck: constants.CertExpiryCheckInterval,
+			expectedEvent: true},
+		{name: "within 1 day", expiresIn: 12 * time.Hour, expectedReason: constants.ReasonCertificateExpiring,
+			expectedThreshold: durationPtr(day), expectedNextCheck: 12 * time.Hour, expectedEvent: true},
+		{name: "expired", expiresIn: -time.Hour, expectedReason: constants.ReasonCertificateExpired,
+			expectedThreshold: durationPtr(0), expectedNextCheck: constants.CertExpiryCheckInterval,
+			expectedEvent: true},
+		{name: "threshold already reported", expiresIn: 20 * day, reported: &metav1.Duration{Duration: 30 * day},
+			expectedReason: constants.ReasonCertificateExpiring, expectedThreshold: durationPtr(30 * day),
+			expectedNextCheck: constants.CertExpiryCheckInterval},
+		{name: "next threshold crossed", expiresIn: 5 * day, reported: &metav1.Duration{Duration: 30 * day},
+			expectedReason: constants.ReasonCertificateExpiring, expectedThreshold: durationPtr(7 * day),
+			expectedNextCheck: constants.CertExpiryCheckInterval, expectedEvent: true},
+		{name: "custom thresholds", expiresIn: 20 * day, thresholds: []metav1.Duration{{Duration: 2 * day}},
+			expectedReason: constants.ReasonCertificatesValid, expectedNextCheck: constants.CertExpiryCheckInterval},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notAfter := time.Now().Add(tt.expiresIn).Truncate(time.Second)
+			later := notAfter.Add(365 * day)
+			// The earliest certificate is the second one of a bundle in the second secret
+			bundle := append(newTestCertificate(t, "later", later), newTestCertificate(t, "earliest", notAfter)...)
+			c, err := newFakeClient([]runtime.Object{
+				newCertSecret("array-cert", map[string][]byte{"cert-0": newTestCertificate(t, "array", later)}),
+				newCertSecret("array-bundle", map[string][]byte{"ca.crt": bundle, "password": []byte("secret")}),
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := record.NewFakeRecorder(10)
+			instance := &v1.CSIIsilon{ObjectMeta: metav1.ObjectMeta{Name: "cert-expiry-" + string(rune('a'+i)),
+				Namespace: "test-isilon"}}
+			var oldExpiry *v1.CertificateExpiry
+			if tt.reported != nil {
+				oldExpiry = &v1.CertificateExpiry{NotAfter: metav1.NewTime(notAfter), Threshold: tt.reported}
+			}
+			conditions := make([]metav1.Condition, 0)
+			expiry, nextCheck := utils.CheckCertificateExpiry(context.Background(), c, recorder, instance, "isilon",
+				[]string{"array-cert", "array-bundle"}, tt.thresholds, oldExpiry, &conditions, ctrl.Log.WithName("test"))
+			if expiry == nil {
+				t.Fatalf("expected the expiry of a certificate")
+			}
+			if expiry.Secret != "array-bundle" || expiry.Key != "ca.crt" || expiry.Subject != "CN=earliest" ||
+				!expiry.NotAfter.Time.Equal(notAfter) {
+				t.Errorf("expected the earliest certificate expiring at %s, got %+v", notAfter, expiry)
+			}
+			if (expiry.Threshold == nil) != (tt.expectedThreshold == nil) ||
+				(expiry.Threshold != nil && expiry.Threshold.Duration != *tt.expectedThreshold) {
+				t.Errorf("expected threshold %v, got %v", tt.expectedThreshold, expiry.Threshold)
+			}
+			// The remaining time shrinks while the test runs
+			if nextCheck > tt.expectedNextCheck || nextCheck < tt.expectedNextCheck-time.Minute {
+				t.Errorf("expected the next check in %s, got %s", tt.expectedNextCheck, nextCheck)
+			}
+			condition := meta.FindStatusCondition(conditions, constants.ConditionCertificateExpiry)
+			if condition == nil || condition.Reason != tt.expectedReason {
+				t.Errorf("expected a condition with reason %s, got %+v", tt.expectedReason, condition)
+			}
+			events := drainEvents(recorder)
+			if tt.expectedEvent {
+				if len(events) != 1 || !strings.HasPrefix(events[0], corev1.EventTypeWarning+" "+tt.expectedReason) {
+					t.Errorf("expected a %s warning event, got %v", tt.expectedReason, events)
+				}
+			} else if len(events) != 0 {
+				t.Errorf("expected no events, got %v", events)
+			}
+			value, ok := getCertificateExpiryMetric(t, instance.Name)
+			if !ok || value != float64(notAfter.Unix()) {
+				t.Errorf("expected the expiry metric %d, got %f (exported %t)", notAfter.Unix(), value, ok)
+			}
+
+			// The metric & the condition are removed along with the certificates
+			expiry, nextCheck = utils.CheckCertificateExpiry(context.Background(), c, recorder, instance, "isilon",
+				[]string{"missing"}, tt.thresholds, expiry, &conditions, ctrl.Log.WithName("test"))
+			if expiry != nil || nextCheck != 0 || len(conditions) != 0 {
+				t.Errorf("expected no certificate expiry, got %+v, %s & %+v", expiry, nextCheck, conditions)
+			}
+			if _, ok := getCertificateExpiryMetric(t, instance.Name); ok {
+				t.Errorf("expected the expiry metric to be removed")
+			}
+		})
+	}
+}
+
+func TestDriverCertificateExpiryEvents(t *testing.T) {
+	notAfter := time.Now().Add(3 * 24 * time.Hour).Truncate(time.Second)
+	reconciler, c := runIsilon(t, newCertSecret("isilon-array-cert", map[string][]byte{
+		"cert-0": newTestCertificate(t, "isilon", notAfter),
+	}))
+	recorder := reconciler.Recorder.(*record.FakeRecorder)
+	drainEvents(recorder)
+	instance := getTestIsilon(t, c)
+	instance.Spec.Driver.CertSecrets = []string{"isilon-array-cert"}
+	if err := c.Update(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestIsilon(reconciler)
+	reconcileTestIsilon(reconciler)
+	status := getTestIsilon(t, c).Status
+	if status.CertificateExpiry == nil || status.CertificateExpiry.Threshold == nil ||
+		status.CertificateExpiry.Threshold.Duration != 7*24*time.Hour {
+		t.Fatalf("expected the certificate to expire within 7 days, got %+v", status.CertificateExpiry)
+	}
+	expiring := 0
+	for _, event := range drainEvents(recorder) {
+		if strings.Contains(event, constants.ReasonCertificateExpiring) {
+			expiring++
+		}
+	}
+	if expiring != 1 {
+		t.Errorf("expected one %s event, got %d", constants.ReasonCertificateExpiring, expiring)
+	}
+
+	// The renewal of the certificate clears the warning
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "isilon-array-cert"}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Data["cert-0"] = newTestCertificate(t, "isilon", notAfter.Add(365*24*time.Hour))
+	if err := c.Update(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestIsilon(reconciler)
+	reconcileTestIsilon(reconciler)
+	status = getTestIsilon(t, c).Status
+	if status.CertificateExpiry == nil || status.CertificateExpiry.Threshold != nil {
+		t.Errorf("expected the renewed certificate to be valid, got %+v", status.CertificateExpiry)
+	}
+	condition := meta.FindStatusCondition(status.Conditions, constants.ConditionCertificateExpiry)
+	if condition == nil || condition.Reason != constants.ReasonCertificatesValid {
+		t.Errorf("expected the certificates to be valid, got %+v", condition)
+	}
+	for _, event := range drainEvents(recorder) {
+		if strings.HasPrefix(event, corev1.EventTypeWarning+" Certificate") {
+			t.Errorf("unexpected event %s", event)
+		}
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
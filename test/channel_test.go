@@ -0,0 +1,170 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func TestResolveConfigVersion(t *testing.T) {
+	opConfig, err := ctrlconfig.ReadOpConfig("../driverconfig", "config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read the operator config: %v", err)
+	}
+	tests := []struct {
+		name          string
+		configVersion string
+		k8sVersion    v1.K8sVersion
+		expected      string
+		expectedErr   bool
+	}{
+		{name: "latest on the newest K8s version", configVersion: "latest", k8sVersion: "v127", expected: "v2.7.0"},
+		{name: "latest falls back to an older config version", configVersion: "latest", k8sVersion: "v123",
+			expected: "v2.6.0"},
+		{name: "stable", configVersion: "stable", k8sVersion: "v125", expected: "v2.6.0"},
+		{name: "stable falls back to an older config version", configVersion: "stable", k8sVersion: "v121",
+			expected: "v2.5.0"},
+		{name: "n-1", configVersion: "n-1", k8sVersion: "v125", expected: "v2.6.0"},
+		{name: "n-1 doesn't fall back to an older config version", configVersion: "n-1", k8sVersion: "v121",
+			expectedErr: true},
+		{name: "config version which isn't an alias", configVersion: "v2.5.0", k8sVersion: "v127", expected: "v2.5.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := opConfig.ResolveConfigVersion(v1.PowerMax, tt.configVersion, tt.k8sVersion)
+			if tt.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error, resolved to %s", resolved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("expected %s, resolved to %s", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestResolveConfigVersionFrom(t *testing.T) {
+	opConfig, err := ctrlconfig.ReadOpConfig("../driverconfig", "config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read the operator config: %v", err)
+	}
+	tests := []struct {
+		name          string
+		configVersion string
+		fromVersion   string
+		k8sVersion    v1.K8sVersion
+		expected      string
+		expectedErr   bool
+	}{
+		{name: "channel move which skips a config version", configVersion: "latest", fromVersion: "v2.5.0",
+			k8sVersion: "v125", expected: "v2.6.0"},
+		{name: "channel head reachable", configVersion: "latest", fromVersion: "v2.6.0", k8sVersion: "v125",
+			expected: "v2.7.0"},
+		{name: "current config version", configVersion: "latest", fromVersion: "v2.7.0", k8sVersion: "v125",
+			expected: "v2.7.0"},
+		{name: "no fall back to an older config version", configVersion: "latest", fromVersion: "v2.7.0",
+			k8sVersion: "v123", expectedErr: true},
+		{name: "no current config version", configVersion: "latest", k8sVersion: "v123", expected: "v2.6.0"},
+		{name: "config version which isn't an alias", configVersion: "v2.5.0", fromVersion: "v2.7.0",
+			k8sVersion: "v125", expected: "v2.5.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := opConfig.ResolveConfigVersionFrom(v1.PowerMax, tt.configVersion, tt.k8sVersion,
+				tt.fromVersion)
+			if tt.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error, resolved to %s", resolved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("expected %s, resolved to %s", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestConfigVersionAliasFollowsUpgradePaths(t *testing.T) {
+	configVersionKey := utils.MetadataPrefix + "/CSIDriverConfigVersion"
+	tests := []struct {
+		name            string
+		alias           string
+		previousVersion string
+		expected        []string
+		expectedBlocked bool
+	}{
+		// The driver moves along the upgrade paths one reconcile at a time
+		{name: "channel move which skips a config version", alias: "latest", previousVersion: "v2.5.0",
+			expected: []string{"v2.6.0", "v2.7.0"}},
+		{name: "channel head reachable", alias: "latest", previousVersion: "v2.6.0",
+			expected: []string{"v2.7.0", "v2.7.0"}},
+		{name: "blocked channel", alias: "stable", previousVersion: "v2.7.0", expected: []string{"v2.7.0", "v2.7.0"},
+			expectedBlocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseSimpleIsilon(t)
+			instance := objects[0].(*v1.CSIIsilon)
+			instance.Spec.Driver.ConfigVersion = tt.alias
+			instance.Spec.Driver.Common.Image = ""
+			instance.Annotations = map[string]string{configVersionKey: tt.previousVersion}
+			reconciler, c, recorder := newIsilonReconciler(t, "../driverconfig", "v125", runningWorkloads{},
+				objects...)
+			for _, expected := range tt.expected {
+				reconcileTestIsilon(reconciler)
+				if configVersion := getTestIsilon(t, c).Annotations[configVersionKey]; configVersion != expected {
+					t.Errorf("expected the config version %s, got %s", expected, configVersion)
+				}
+			}
+			status := getTestIsilon(t, c).Status
+			if status.ResolvedConfigVersion != tt.expected[len(tt.expected)-1] {
+				t.Errorf("expected the alias to resolve to %s, got %s", tt.expected[len(tt.expected)-1],
+					status.ResolvedConfigVersion)
+			}
+			if status.State == constants.InvalidConfig {
+				t.Errorf("expected the driver to follow the channel, got %s", status.LastUpdate.ErrorMessage)
+			}
+			blocked := meta.IsStatusConditionTrue(status.Conditions, constants.ConditionChannelBlocked)
+			if blocked != tt.expectedBlocked {
+				t.Errorf("expected the channel blocked condition to be %v, got %v", tt.expectedBlocked, blocked)
+			}
+			warnings := 0
+			for _, event := range drainEvents(recorder) {
+				if strings.HasPrefix(event, "Warning "+constants.ReasonChannelBlocked) {
+					warnings++
+				}
+			}
+			if tt.expectedBlocked && warnings != 1 {
+				t.Errorf("expected one %s event, got %d", constants.ReasonChannelBlocked, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/controllers"
+	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// newPowerMaxReconciler - Returns a PowerMax reconciler using a fake client holding the objects
+func newPowerMaxReconciler(t *testing.T, objects ...runtime.Object) (*controllers.CSIPowerMaxReconciler, *fakeClient,
+	*record.FakeRecorder) {
+	c, err := newFakeClient(objects, runningWorkloads{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(20)
+	reconciler := &controllers.CSIPowerMaxReconciler{
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIPowerMax"),
+		Recorder: recorder,
+	}
+	reconciler.SetClient(c)
+	reconciler.SetScheme(scheme.Scheme)
+	reconciler.SetConfig(operatorconfig.Config{
+		ConfigDirectory:      "../driverconfig",
+		ConfigFile:           "config.yaml",
+		KubeAPIServerVersion: "v125",
+		EnabledDrivers:       []v1.DriverType{v1.PowerMax},
+		RetryCount:           1,
+	})
+	return reconciler, c, recorder
+}
+
+// reconcileTestPowerMax - Runs one reconcile of the test PowerMax CR
+func reconcileTestPowerMax(reconciler *controllers.CSIPowerMaxReconciler) {
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "test-powermax", Name: "test-powermax"},
+	})
+}
+
+// getCHAPSecret - Returns the CHAP secret generated for the test PowerMax CR
+func getCHAPSecret(t *testing.T, c *fakeClient) *corev1.Secret {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "test-powermax-chap"}, secret)
+	if err != nil {
+		t.Fatalf("failed to get the CHAP secret: %v", err)
+	}
+	return secret
+}
+
+// getPowerMaxNodeSecretsChecksum - Returns the checksum of the secrets stamped on the pod template of the node plugin
+func getPowerMaxNodeSecretsChecksum(t *testing.T, c *fakeClient) string {
+	daemonSet := &appsv1.DaemonSet{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "powermax-node"}, daemonSet)
+	if err != nil {
+		t.Fatalf("failed to get the node daemonset: %v", err)
+	}
+	return daemonSet.Spec.Template.Annotations[constants.PodTemplateSecretsChecksumKey]
+}
+
+func TestCHAPSecretGenerationAndRotation(t *testing.T) {
+	dir := "testdata/csipowermax/07-chap-auto-generate/"
+	objects := parseTestObjects(t, dir+"in-csipowermax.yaml", dir+"in-csipowermax-secret.yaml",
+		dir+"in-vcenter-secret.yaml")
+	reconciler, c, recorder := newPowerMaxReconciler(t, objects...)
+	reconcileTestPowerMax(reconciler)
+	reconcileTestPowerMax(reconciler)
+
+	// Generation
+	secret := getCHAPSecret(t, c)
+	instance := &v1.CSIPowerMax{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "test-powermax"}, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !metav1.IsControlledBy(secret, instance) {
+		t.Errorf("expected the CHAP secret to be controlled by the CR, got owners %v", secret.OwnerReferences)
+	}
+	chapSecret := secret.Data["chapsecret"]
+	if !regexp.MustCompile("^[a-zA-Z0-9]{16}$").Match(chapSecret) {
+		t.Errorf("expected a CHAP secret of 16 alphanumeric characters, got %q", chapSecret)
+	}
+	if rotated, ok := secret.Annotations[constants.CHAPRotatedAnnotation]; !ok || rotated != "" {
+		t.Errorf("expected the CHAP secret to be annotated with an empty rotation, got %v", secret.Annotations)
+	}
+	checksum := getPowerMaxNodeSecretsChecksum(t, c)
+	if checksum == "" {
+		t.Errorf("expected the checksum of the CHAP secret on the pod template of the node plugin")
+	}
+	drainEvents(recorder)
+
+	// The CHAP secret is kept as long as the rotate annotation is unchanged
+	reconcileTestPowerMax(reconciler)
+	if !bytes.Equal(getCHAPSecret(t, c).Data["chapsecret"], chapSecret) {
+		t.Errorf("expected the CHAP secret to be kept")
+	}
+
+	// Rotation
+	instance.Annotations[constants.CHAPRotateAnnotation] = "2026-10-19"
+	if err = c.Update(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestPowerMax(reconciler)
+	reconcileTestPowerMax(reconciler)
+	secret = getCHAPSecret(t, c)
+	if bytes.Equal(secret.Data["chapsecret"], chapSecret) {
+		t.Errorf("expected the CHAP secret to be rotated")
+	}
+	if rotated := secret.Annotations[constants.CHAPRotatedAnnotation]; rotated != "2026-10-19" {
+		t.Errorf("expected the CHAP secret to be annotated with the rotation 2026-10-19, got %q", rotated)
+	}
+	if getPowerMaxNodeSecretsChecksum(t, c) == checksum {
+		t.Errorf("expected the node plugin to be rolled out with the rotated CHAP secret")
+	}
+	expectedEvent := "Normal CHAPSecretRotated CHAP secret test-powermax-chap rotated"
+	found := false
+	for _, event := range drainEvents(recorder) {
+		if event == expectedEvent {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the event %q", expectedEvent)
+	}
+}
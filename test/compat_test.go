@@ -0,0 +1,210 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// hostPathVolume - Returns a volume with a host path source
+func hostPathVolume(name, path string) corev1.Volume {
+	return corev1.Volume{
+		Name:         name,
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}},
+	}
+}
+
+func TestCompareDriverConfigs(t *testing.T) {
+	from := &ctrlconfig.DriverConfig{
+		DriverEnvs: []ctrlconfig.DriverEnv{
+			{Name: "X_CSI_KEPT"},
+			{Name: "X_CSI_OPTIONAL", Mandatory: false},
+			{Name: "X_CSI_DROPPED"},
+			{Name: "X_CSI_OLD_NAME"},
+			{Name: "X_CSI_SUPERSEDED"},
+		},
+		ControllerVolumes: []corev1.Volume{hostPathVolume("certs", "/certs")},
+		NodeVolumes:       []corev1.Volume{hostPathVolume("driver-path", "/var/lib/driver"), hostPathVolume("dev", "/dev")},
+		SidecarParams: []ctrlconfig.SidecarParams{
+			{Name: v1.Provisioner}, {Name: v1.Snapshotter, Optional: true}, {Name: v1.Resizer},
+		},
+		StorageClassParams: []ctrlconfig.StorageClassParam{{Name: "Pool", Mandatory: true}, {Name: "Zone"}},
+	}
+	to := &ctrlconfig.DriverConfig{
+		DriverEnvs: []ctrlconfig.DriverEnv{
+			{Name: "X_CSI_KEPT"},
+			{Name: "X_CSI_OPTIONAL", Mandatory: true},
+			{Name: "X_CSI_NEW_NAME", DeprecatedNames: []string{"X_CSI_OLD_NAME"}},
+			{Name: "X_CSI_ADDED"},
+			{Name: "X_CSI_SUPERSEDED", Removed: true, ReplacedBy: "X_CSI_ADDED"},
+		},
+		ControllerVolumes: []corev1.Volume{hostPathVolume("certs", "/certs")},
+		NodeVolumes: []corev1.Volume{hostPathVolume("plugin-dir", "/var/lib/driver"),
+			hostPathVolume("sys", "/sys")},
+		SidecarParams: []ctrlconfig.SidecarParams{
+			{Name: v1.Provisioner}, {Name: v1.Snapshotter}, {Name: v1.Sdcmonitor, Optional: true},
+		},
+		StorageClassParams: []ctrlconfig.StorageClassParam{{Name: "Pool", Mandatory: true}, {Name: "Zone", Mandatory: true}},
+	}
+	report := ctrlconfig.CompareDriverConfigs("v2.6.0", from, "v2.7.0", to)
+	expected := &ctrlconfig.CompatibilityReport{
+		FromVersion:      "v2.6.0",
+		ToVersion:        "v2.7.0",
+		AddedEnvs:        []string{"X_CSI_NEW_NAME", "X_CSI_ADDED"},
+		RemovedEnvs:      []string{"X_CSI_DROPPED"},
+		NewMandatoryEnvs: []string{"X_CSI_OPTIONAL"},
+		RenamedEnvs: []ctrlconfig.EnvRename{
+			{From: "X_CSI_OLD_NAME", To: "X_CSI_NEW_NAME"},
+			{From: "X_CSI_SUPERSEDED", To: "X_CSI_ADDED"},
+		},
+		RenamedVolumes:                 []ctrlconfig.VolumeRename{{From: "driver-path", To: "plugin-dir"}},
+		AddedVolumes:                   []string{"sys"},
+		RemovedVolumes:                 []string{"dev"},
+		AddedSidecars:                  []string{string(v1.Sdcmonitor)},
+		RemovedSidecars:                []string{string(v1.Resizer)},
+		NewMandatorySidecars:           []string{string(v1.Snapshotter)},
+		NewMandatoryStorageClassParams: []string{"Zone"},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("expected report\n%+v\ngot\n%+v", expected, report)
+	}
+
+	report.CheckDriverSpec(&v1.Driver{
+		Common: v1.ContainerTemplate{Envs: []corev1.EnvVar{{Name: "X_CSI_KEPT"}, {Name: "X_CSI_DROPPED"}}},
+		Node: v1.NodeTemplate{ContainerTemplate: v1.ContainerTemplate{
+			Envs: []corev1.EnvVar{{Name: "X_CSI_SUPERSEDED"}},
+		}},
+	})
+	if !reflect.DeepEqual(report.UnsupportedEnvs, []string{"X_CSI_DROPPED"}) {
+		t.Errorf("expected unsupported envs [X_CSI_DROPPED], got %v", report.UnsupportedEnvs)
+	}
+	if !reflect.DeepEqual(report.MissingMandatoryEnvs, []string{"X_CSI_OPTIONAL"}) {
+		t.Errorf("expected missing mandatory envs [X_CSI_OPTIONAL], got %v", report.MissingMandatoryEnvs)
+	}
+	if report.IsCompatible() {
+		t.Errorf("expected the driver spec to be incompatible")
+	}
+}
+
+func TestCompareIdenticalDriverConfigs(t *testing.T) {
+	driverConfig, k8sVersion, err := ctrlconfig.ReadDriverConfig("../driverconfig", v1.Isilon, "v2.7.0", "v125")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k8sVersion != "v125" {
+		t.Errorf("expected the driver config for v125, got %s", k8sVersion)
+	}
+	report := ctrlconfig.CompareDriverConfigs("v2.7.0", driverConfig, "v2.7.0", driverConfig)
+	if report.String() != "Changes from v2.7.0 to v2.7.0: none" {
+		t.Errorf("expected no changes, got %s", report.String())
+	}
+	report.CheckDriverSpec(&v1.Driver{})
+	if !report.IsCompatible() {
+		t.Errorf("expected the driver spec to be compatible")
+	}
+}
+
+func TestReadDriverConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		configVersion      string
+		k8sVersion         v1.K8sVersion
+		expectedK8sVersion v1.K8sVersion
+		expectedErr        bool
+	}{
+		{name: "supported K8s version", configVersion: "v2.5.0", k8sVersion: "v123", expectedK8sVersion: "v123"},
+		{name: "falls back to the latest K8s version", configVersion: "v2.5.0", k8sVersion: "v127",
+			expectedK8sVersion: "v125"},
+		{name: "unknown config version", configVersion: "v1.0.0", k8sVersion: "v125", expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, k8sVersion, err := ctrlconfig.ReadDriverConfig("../driverconfig", v1.Isilon, tt.configVersion, tt.k8sVersion)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if k8sVersion != tt.expectedK8sVersion {
+				t.Errorf("expected the driver config for %s, got %s", tt.expectedK8sVersion, k8sVersion)
+			}
+		})
+	}
+}
+
+func TestCompareClusterRoles(t *testing.T) {
+	from := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"nodes", "pods"}, Verbs: []string{"get", "list"}},
+		{APIGroups: []string{"coordination.k8s.io"}, Resources: []string{"leases"}, Verbs: []string{"get", "update"}},
+	}
+	to := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "get"}},
+		{APIGroups: []string{"security.openshift.io"}, Resources: []string{"securitycontextconstraints"},
+			ResourceNames: []string{"privileged"}, Verbs: []string{"use"}},
+	}
+	report := &ctrlconfig.CompatibilityReport{}
+	report.CompareClusterRoles("node", from, to)
+	expected := []string{
+		"node cluster role: added use on security.openshift.io/securitycontextconstraints/privileged",
+		"node cluster role: added watch on nodes",
+		"node cluster role: removed get,update on coordination.k8s.io/leases",
+	}
+	if !reflect.DeepEqual(report.RBACChanges, expected) {
+		t.Errorf("expected rbac changes %v, got %v", expected, report.RBACChanges)
+	}
+}
+
+func TestGetEnvReplacements(t *testing.T) {
+	tests := []struct {
+		driverType v1.DriverType
+		expected   map[string]string
+	}{
+		{driverType: v1.Isilon, expected: map[string]string{
+			"X_CSI_ISI_INSECURE": "X_CSI_ISI_SKIP_CERTIFICATE_VALIDATION",
+		}},
+		{driverType: v1.PowerMax, expected: map[string]string{
+			"X_CSI_POWERMAX_INSECURE": "X_CSI_POWERMAX_SKIP_CERTIFICATE_VALIDATION",
+			"X_CSI_POWERMAX_VERSION":  "",
+		}},
+		{driverType: v1.Unity, expected: map[string]string{
+			"X_CSI_UNITY_ENDPOINT": "",
+			"X_CSI_UNITY_USER":     "",
+			"X_CSI_UNITY_PASSWORD": "",
+			"X_CSI_UNITY_INSECURE": "",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.driverType), func(t *testing.T) {
+			driverConfig, _, err := ctrlconfig.ReadDriverConfig("../driverconfig", tt.driverType, "v2.7.0", "v125")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replacements := driverConfig.GetEnvReplacements(); !reflect.DeepEqual(replacements, tt.expected) {
+				t.Errorf("expected replacements %v, got %v", tt.expected, replacements)
+			}
+		})
+	}
+}
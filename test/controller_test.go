@@ -31,7 +31,6 @@ import (
 	v1 "github.com/dell/dell-csi-operator/api/v1"
 	"github.com/dell/dell-csi-operator/controllers"
 	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
-	"github.com/dell/dell-csi-operator/pkg/resources/statefulset"
 	"github.com/dell/dell-csi-operator/pkg/utils"
 	snaps "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	"github.com/stretchr/testify/suite"
@@ -41,6 +40,7 @@ import (
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/api/storage/v1beta1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
@@ -52,6 +52,9 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// testSecretSourceNamespace - Namespace from which the test CRs can reference secrets
+const testSecretSourceNamespace = "storage-secrets"
+
 type ControllerTestSuite struct {
 	suite.Suite
 	configDir  string
@@ -83,8 +86,6 @@ func (suite *ControllerTestSuite) SetupSuite() {
 		suite.Fail("cannot add to scheme: ", err)
 	}
 
-	// Set statefulset sleep timeout to 0
-	statefulset.SleepTime = 0 * time.Second
 	suite.configFile = "config.yaml"
 	suite.configDir = "../driverconfig"
 	suite.drivers = []Driver{
@@ -113,6 +114,8 @@ func (suite *ControllerTestSuite) SetupSuite() {
 				}
 				expPowerMax.Status.LastUpdate.Time.Time = gotPowerMax.Status.LastUpdate.Time.Time.Truncate(time.Second)
 				expPowerMax.Status.DriverHash = gotPowerMax.Status.DriverHash
+				copyRolloutStatus(&expPowerMax.Status, &gotPowerMax.Status)
+				copyConditionTimes(expPowerMax.Status.Conditions, gotPowerMax.Status.Conditions)
 				return nil
 			},
 		},
@@ -141,6 +144,8 @@ func (suite *ControllerTestSuite) SetupSuite() {
 				}
 				expPowerStore.Status.LastUpdate.Time.Time = gotPowerStore.Status.LastUpdate.Time.Time.Truncate(time.Second)
 				expPowerStore.Status.DriverHash = gotPowerStore.Status.DriverHash
+				copyRolloutStatus(&expPowerStore.Status, &gotPowerStore.Status)
+				copyConditionTimes(expPowerStore.Status.Conditions, gotPowerStore.Status.Conditions)
 				return nil
 			},
 		},
@@ -169,6 +174,8 @@ func (suite *ControllerTestSuite) SetupSuite() {
 				}
 				expCSIVXFlexOS.Status.LastUpdate.Time.Time = gotCSIVXFlexOS.Status.LastUpdate.Time.Time.Truncate(time.Second)
 				expCSIVXFlexOS.Status.DriverHash = gotCSIVXFlexOS.Status.DriverHash
+				copyRolloutStatus(&expCSIVXFlexOS.Status, &gotCSIVXFlexOS.Status)
+				copyConditionTimes(expCSIVXFlexOS.Status.Conditions, gotCSIVXFlexOS.Status.Conditions)
 				return nil
 			},
 		},
@@ -222,12 +229,48 @@ func (suite *ControllerTestSuite) SetupSuite() {
 				}
 				expIsilon.Status.LastUpdate.Time.Time = gotIsilon.Status.LastUpdate.Time.Time.Truncate(time.Second)
 				expIsilon.Status.DriverHash = gotIsilon.Status.DriverHash
+				copyRolloutStatus(&expIsilon.Status, &gotIsilon.Status)
+				copyConditionTimes(expIsilon.Status.Conditions, gotIsilon.Status.Conditions)
+				if expIsilon.Status.NodeRollout != nil && gotIsilon.Status.NodeRollout != nil {
+					lastBatchTime := metav1.NewTime(gotIsilon.Status.NodeRollout.LastBatchTime.Time.Truncate(time.Second))
+					expIsilon.Status.NodeRollout.LastBatchTime = &lastBatchTime
+				}
+				if expIsilon.Status.PendingUpdate != nil && gotIsilon.Status.PendingUpdate != nil {
+					// The next window depends on the time at which the test is run
+					expIsilon.Status.PendingUpdate.DriverHash = gotIsilon.Status.PendingUpdate.DriverHash
+					expIsilon.Status.PendingUpdate.NextWindow = gotIsilon.Status.PendingUpdate.NextWindow
+					expIsilon.Status.PendingUpdate.Message = gotIsilon.Status.PendingUpdate.Message
+				}
 				return nil
 			},
 		},
 	}
 }
 
+// copyRolloutStatus - copies the start time of the rollout, which depends on the test run
+// The rollout is expected to target the driver hash, which has already been copied to the expected status
+func copyRolloutStatus(expStatus, gotStatus *v1.DriverStatus) {
+	if expStatus.Rollout == nil || gotStatus.Rollout == nil {
+		return
+	}
+	expStatus.Rollout.TargetDriverHash = expStatus.DriverHash
+	if gotStatus.Rollout.StartTime != nil {
+		startTime := metav1.NewTime(gotStatus.Rollout.StartTime.Time.Truncate(time.Second))
+		expStatus.Rollout.StartTime = &startTime
+	}
+}
+
+// copyConditionTimes - copies the transition times of the conditions recorded during the test run
+func copyConditionTimes(expConditions, gotConditions []metav1.Condition) {
+	for i := range expConditions {
+		for _, gotCondition := range gotConditions {
+			if gotCondition.Type == expConditions[i].Type {
+				expConditions[i].LastTransitionTime = metav1.NewTime(gotCondition.LastTransitionTime.Time.Truncate(time.Second))
+			}
+		}
+	}
+}
+
 func (suite *ControllerTestSuite) TestAllControllers() {
 	for _, driver := range suite.drivers {
 		suite.Run(string(driver.driverType), func() {
@@ -297,7 +340,8 @@ func (suite *ControllerTestSuite) testDirectory(driver *Driver, path string) {
 		EnabledDrivers: []v1.DriverType{
 			driver.driverType,
 		},
-		RetryCount: 1,
+		RetryCount:             1,
+		SecretSourceNamespaces: []string{testSecretSourceNamespace},
 	}
 
 	driver.reconciler.SetClient(c)
@@ -348,8 +392,9 @@ func (suite *ControllerTestSuite) testDirectoryWithErrors(driver *Driver, path s
 		EnabledDrivers: []v1.DriverType{
 			driver.driverType,
 		},
-		KubeAPIServerVersion: driver.k8sVersion,
-		RetryCount:           100,
+		KubeAPIServerVersion:   driver.k8sVersion,
+		RetryCount:             100,
+		SecretSourceNamespaces: []string{testSecretSourceNamespace},
 	}
 
 	driver.reconciler.SetClient(c)
@@ -564,7 +609,8 @@ func (revSuite *ReverseProxyControllerTestSuite) SetupSuite() {
 		revSuite.Fail("cannot add to scheme", err)
 	}
 	revSuite.revProxyReconciler = &controllers.CSIPowerMaxRevProxyReconciler{
-		Log: ctrl.Log.WithName("controllers").WithName("CSIPowerMaxRevProxy"),
+		Log:    ctrl.Log.WithName("controllers").WithName("CSIPowerMaxRevProxy"),
+		Config: operatorconfig.Config{SecretSourceNamespaces: []string{testSecretSourceNamespace}},
 	}
 	revSuite.k8sVersion = "v122"
 	revSuite.name = "powermaxrevproxy"
@@ -809,6 +855,7 @@ func (revProxy *ReverseProxyControllerTestSuite) copyTime(expObj runtime.Object,
 		return fmt.Errorf("can't convert object to CSIPowerMaxRevProxy")
 	}
 	expPowerMax.Status.LastUpdate.Time.Time = gotPowerMax.Status.LastUpdate.Time.Time.Truncate(time.Second)
+	copyConditionTimes(expPowerMax.Status.Conditions, gotPowerMax.Status.Conditions)
 	return nil
 }
 
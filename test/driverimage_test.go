@@ -0,0 +1,61 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+)
+
+func TestDriverImageMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		expected []string
+	}{
+		{name: "image of another config version", image: "dellemc/csi-isilon:v2.6.0",
+			expected: []string{"Warning " + constants.ReasonDriverImageMismatch +
+				" Driver image tag (v2.6.0) doesn't match the config version (v2.7.0)"}},
+		{name: "image of the config version", image: "dellemc/csi-isilon:v2.7.0"},
+		{name: "default image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseSimpleIsilon(t)
+			instance := objects[0].(*v1.CSIIsilon)
+			instance.Spec.Driver.ConfigVersion = "v2.7.0"
+			instance.Spec.Driver.Common.Image = tt.image
+			reconciler, _, recorder := newIsilonReconciler(t, "../driverconfig", "v125", nil, objects...)
+			reconcileTestIsilon(reconciler)
+			reconcileTestIsilon(reconciler)
+			warnings := make([]string, 0)
+			for _, event := range drainEvents(recorder) {
+				if strings.Contains(event, constants.ReasonDriverImageMismatch) {
+					warnings = append(warnings, event)
+				}
+			}
+			if len(tt.expected) == 0 {
+				tt.expected = []string{}
+			}
+			if !reflect.DeepEqual(warnings, tt.expected) {
+				t.Errorf("expected the events %v, got %v", tt.expected, warnings)
+			}
+		})
+	}
+}
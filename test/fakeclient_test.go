@@ -23,9 +23,12 @@ import (
 
 	snaps "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 
+	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -112,6 +115,10 @@ func (f *fakeClient) Get(ctx context.Context, key client.ObjectKey, obj client.O
 	if err != nil {
 		return err
 	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		// Kinds like the cert-manager CRDs aren't registered in the scheme
+		return u.UnmarshalJSON(j)
+	}
 	decoder := scheme.Codecs.UniversalDecoder()
 	_, _, err = decoder.Decode(j, nil, obj)
 	return err
@@ -128,6 +135,12 @@ func (f *fakeClient) List(ctx context.Context, list client.ObjectList, opts ...c
 		return f.listStorageClasses(list.(*storagev1.StorageClassList))
 	case *snaps.VolumeSnapshotClassList:
 		return f.listVolumeSnapshots(list.(*snaps.VolumeSnapshotClassList))
+	case *corev1.PodList:
+		return f.listPods(list.(*corev1.PodList), opts...)
+	case *corev1.SecretList:
+		return f.listSecrets(list.(*corev1.SecretList), opts...)
+	case *storagev1.VolumeAttachmentList:
+		return f.listVolumeAttachments(list.(*storagev1.VolumeAttachmentList))
 	default:
 		return fmt.Errorf("Unknown type: %s", reflect.TypeOf(list))
 	}
@@ -151,6 +164,53 @@ func (f *fakeClient) listVolumeSnapshots(list *snaps.VolumeSnapshotClassList) er
 	return nil
 }
 
+func (f *fakeClient) listPods(list *corev1.PodList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	for k, v := range f.objects {
+		if k.Kind != "Pod" {
+			continue
+		}
+		pod := v.(*corev1.Pod)
+		if listOpts.Namespace != "" && pod.Namespace != listOpts.Namespace {
+			continue
+		}
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		list.Items = append(list.Items, *pod)
+	}
+	return nil
+}
+
+func (f *fakeClient) listSecrets(list *corev1.SecretList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	for k, v := range f.objects {
+		if k.Kind != "Secret" {
+			continue
+		}
+		secret := v.(*corev1.Secret)
+		if listOpts.Namespace != "" && secret.Namespace != listOpts.Namespace {
+			continue
+		}
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(secret.Labels)) {
+			continue
+		}
+		list.Items = append(list.Items, *secret)
+	}
+	return nil
+}
+
+func (f *fakeClient) listVolumeAttachments(list *storagev1.VolumeAttachmentList) error {
+	for k, v := range f.objects {
+		if k.Kind == "VolumeAttachment" {
+			list.Items = append(list.Items, *v.(*storagev1.VolumeAttachment))
+		}
+	}
+	return nil
+}
+
 func (f *fakeClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
 	if f.errorInjector != nil {
 		if err := f.errorInjector.shouldFail("Create", obj); err != nil {
@@ -0,0 +1,187 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newMaintenanceWindow - Returns a maintenance window with the given schedule, duration & time zone
+func newMaintenanceWindow(schedule string, duration time.Duration, timeZone string) v1.MaintenanceWindow {
+	return v1.MaintenanceWindow{
+		Schedule: schedule,
+		Duration: metav1.Duration{Duration: duration},
+		TimeZone: timeZone,
+	}
+}
+
+// utcTime - Returns the given minute in UTC
+func utcTime(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestGetNextMaintenanceWindow(t *testing.T) {
+	daily := newMaintenanceWindow("0 2 * * *", time.Hour, "")
+	tests := []struct {
+		name             string
+		windows          []v1.MaintenanceWindow
+		now              time.Time
+		expectedInWindow bool
+		expectedStart    time.Time
+		expectedErr      bool
+	}{
+		{name: "before a daily window", windows: []v1.MaintenanceWindow{daily},
+			now: utcTime(2026, 10, 19, 1, 30), expectedStart: utcTime(2026, 10, 19, 2, 0)},
+		{name: "start of a daily window", windows: []v1.MaintenanceWindow{daily},
+			now: utcTime(2026, 10, 19, 2, 0), expectedInWindow: true, expectedStart: utcTime(2026, 10, 19, 2, 0)},
+		{name: "inside a daily window", windows: []v1.MaintenanceWindow{daily},
+			now: utcTime(2026, 10, 19, 2, 30), expectedInWindow: true, expectedStart: utcTime(2026, 10, 19, 2, 0)},
+		{name: "end of a daily window", windows: []v1.MaintenanceWindow{daily},
+			now: utcTime(2026, 10, 19, 3, 0), expectedStart: utcTime(2026, 10, 20, 2, 0)},
+		{name: "range of weekdays on a saturday",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 22 * * 1-5", 2*time.Hour, "")},
+			now:     utcTime(2026, 10, 24, 12, 0), expectedStart: utcTime(2026, 10, 26, 22, 0)},
+		{name: "window of a weekday which ends on the next day",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 22 * * 1-5", 2*time.Hour, "")},
+			now:     utcTime(2026, 10, 23, 23, 30), expectedInWindow: true, expectedStart: utcTime(2026, 10, 23, 22, 0)},
+		{name: "step of minutes",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("*/15 * * * *", 5*time.Minute, "")},
+			now:     utcTime(2026, 10, 19, 10, 7), expectedStart: utcTime(2026, 10, 19, 10, 15)},
+		{name: "inside a window with a step of minutes",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("*/15 * * * *", 5*time.Minute, "")},
+			now:     utcTime(2026, 10, 19, 10, 16), expectedInWindow: true, expectedStart: utcTime(2026, 10, 19, 10, 15)},
+		{name: "range of hours with a step",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 0-23/6 * * *", time.Hour, "")},
+			now:     utcTime(2026, 10, 19, 13, 0), expectedStart: utcTime(2026, 10, 19, 18, 0)},
+		{name: "list of hours",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 1,13 * * *", 30*time.Minute, "")},
+			now:     utcTime(2026, 10, 19, 2, 0), expectedStart: utcTime(2026, 10, 19, 13, 0)},
+		{name: "day of month matches before day of week",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 15 * 0", time.Hour, "")},
+			now:     utcTime(2026, 10, 13, 4, 0), expectedStart: utcTime(2026, 10, 15, 3, 0)},
+		{name: "day of week matches before day of month",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 15 * 0", time.Hour, "")},
+			now:     utcTime(2026, 10, 16, 4, 0), expectedStart: utcTime(2026, 10, 18, 3, 0)},
+		{name: "day of month with an unrestricted day of week",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 15 * *", time.Hour, "")},
+			now:     utcTime(2026, 10, 16, 4, 0), expectedStart: utcTime(2026, 11, 15, 3, 0)},
+		{name: "day of week with a step of 1 is unrestricted",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 15 * */1", time.Hour, "")},
+			now:     utcTime(2026, 10, 16, 4, 0), expectedStart: utcTime(2026, 11, 15, 3, 0)},
+		{name: "7 is sunday",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 * * 7", time.Hour, "")},
+			now:     utcTime(2026, 10, 19, 4, 0), expectedStart: utcTime(2026, 10, 25, 3, 0)},
+		{name: "leap day",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 0 29 2 *", time.Minute, "")},
+			now:     utcTime(2026, 10, 19, 0, 0), expectedStart: utcTime(2028, 2, 29, 0, 0)},
+		{name: "time zone",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 2 * * *", time.Hour, "America/New_York")},
+			now:     utcTime(2026, 10, 19, 5, 30), expectedStart: utcTime(2026, 10, 19, 6, 0)},
+		{name: "inside a window in a time zone",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 2 * * *", time.Hour, "America/New_York")},
+			now:     utcTime(2026, 10, 19, 6, 30), expectedInWindow: true, expectedStart: utcTime(2026, 10, 19, 6, 0)},
+		{name: "start skipped by the switch to daylight saving time",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("30 2 * * *", time.Hour, "America/New_York")},
+			now:     utcTime(2027, 3, 14, 6, 0), expectedStart: utcTime(2027, 3, 15, 6, 30)},
+		{name: "start in local time after the switch from daylight saving time",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 3 * * *", time.Hour, "America/New_York")},
+			now:     utcTime(2026, 10, 31, 8, 0), expectedStart: utcTime(2026, 11, 1, 8, 0)},
+		{name: "earliest of the windows",
+			windows: []v1.MaintenanceWindow{
+				newMaintenanceWindow("0 22 * * *", time.Hour, ""),
+				newMaintenanceWindow("0 6 * * *", time.Hour, "Asia/Tokyo"),
+			},
+			now: utcTime(2026, 10, 19, 12, 0), expectedStart: utcTime(2026, 10, 19, 21, 0)},
+		{name: "missing field", windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 2 * *", time.Hour, "")},
+			expectedErr: true},
+		{name: "invalid value", windows: []v1.MaintenanceWindow{newMaintenanceWindow("a 2 * * *", time.Hour, "")},
+			expectedErr: true},
+		{name: "out of range", windows: []v1.MaintenanceWindow{newMaintenanceWindow("60 2 * * *", time.Hour, "")},
+			expectedErr: true},
+		{name: "step of 0", windows: []v1.MaintenanceWindow{newMaintenanceWindow("*/0 2 * * *", time.Hour, "")},
+			expectedErr: true},
+		{name: "inverted range", windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 5-1 * * *", time.Hour, "")},
+			expectedErr: true},
+		{name: "schedule which never matches",
+			windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 0 30 2 *", time.Hour, "")}, expectedErr: true},
+		{name: "invalid time zone",
+			windows:     []v1.MaintenanceWindow{newMaintenanceWindow("0 2 * * *", time.Hour, "Mars/Olympus_Mons")},
+			expectedErr: true},
+		{name: "duration of 0", windows: []v1.MaintenanceWindow{newMaintenanceWindow("0 2 * * *", 0, "")},
+			expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inWindow, start, err := utils.GetNextMaintenanceWindow(tt.windows, tt.now)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if inWindow != tt.expectedInWindow {
+				t.Errorf("expected in window %t, got %t", tt.expectedInWindow, inWindow)
+			}
+			if !start.Equal(tt.expectedStart) {
+				t.Errorf("expected start %s, got %s", tt.expectedStart, start.UTC())
+			}
+		})
+	}
+}
+
+func TestForceUpdateWaitsForMaintenanceWindow(t *testing.T) {
+	reconciler, c := runIsilon(t)
+	instance := getTestIsilon(t, c)
+	// Leap days only, so the window is never active while the test runs
+	instance.Spec.Driver.MaintenanceWindows = []v1.MaintenanceWindow{
+		newMaintenanceWindow("0 0 29 2 *", time.Minute, ""),
+	}
+	instance.Spec.Driver.ForceUpdate = true
+	if err := c.Update(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestIsilon(reconciler)
+	instance = getTestIsilon(t, c)
+	if instance.Spec.Driver.ForceUpdate {
+		t.Errorf("expected forceUpdate to be replaced by restartNonce")
+	}
+	restartNonce := instance.Spec.Driver.RestartNonce
+	if restartNonce == "" {
+		t.Fatalf("expected restartNonce to be set")
+	}
+	if instance.Status.PendingUpdate == nil {
+		t.Errorf("expected a pending update")
+	}
+	if instance.Status.State != constants.Running || instance.Status.RestartNonce != "" {
+		t.Errorf("expected the restart to be deferred, got state %s & restart nonce %q",
+			instance.Status.State, instance.Status.RestartNonce)
+	}
+	// The same restart stays pending
+	reconcileTestIsilon(reconciler)
+	instance = getTestIsilon(t, c)
+	if instance.Spec.Driver.RestartNonce != restartNonce {
+		t.Errorf("expected restartNonce %s, got %s", restartNonce, instance.Spec.Driver.RestartNonce)
+	}
+}
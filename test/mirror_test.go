@@ -0,0 +1,124 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/resources"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// newMirrorOwner - Returns the owner reference set on the secrets mirrored for a PowerMax CR
+func newMirrorOwner(name string) metav1.OwnerReference {
+	return metav1.OwnerReference{APIVersion: "storage.dell.com/v1", Kind: "CSIPowerMax", Name: name,
+		UID: types.UID(name)}
+}
+
+// newMirroredSecret - Returns a copy of the secret storage-secrets/<name> in the PowerMax test namespace
+func newMirroredSecret(name string, owners ...metav1.OwnerReference) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "test-powermax",
+			Annotations:     map[string]string{constants.MirroredFromAnnotation: "storage-secrets/" + name},
+			OwnerReferences: owners,
+		},
+		Data: map[string][]byte{"password": []byte("old")},
+	}
+}
+
+func TestMirrorSecrets(t *testing.T) {
+	owner, other := newMirrorOwner("test-powermax"), newMirrorOwner("other-powermax")
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "powermax-creds", Namespace: "storage-secrets",
+			Labels: map[string]string{"app": "powermax"}},
+		Data: map[string][]byte{"password": []byte("new")},
+	}
+	local := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "local-creds", Namespace: "test-powermax"}}
+	tests := []struct {
+		name           string
+		refs           []string
+		copies         []*corev1.Secret
+		expectedOwners map[string][]metav1.OwnerReference
+	}{
+		{name: "new copy", refs: []string{"storage-secrets/powermax-creds"},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": {owner}}},
+		{name: "copy shared with another CR", refs: []string{"storage-secrets/powermax-creds"},
+			copies:         []*corev1.Secret{newMirroredSecret("powermax-creds", other)},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": {other, owner}}},
+		{name: "copy no longer referenced", refs: []string{"local-creds"},
+			copies:         []*corev1.Secret{newMirroredSecret("powermax-creds", owner)},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": nil, "local-creds": nil}},
+		{name: "shared copy no longer referenced",
+			copies:         []*corev1.Secret{newMirroredSecret("powermax-creds", other, owner)},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": {other}}},
+		{name: "copy referenced by another CR only",
+			copies:         []*corev1.Secret{newMirroredSecret("powermax-creds", other)},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": {other}}},
+		{name: "copy replaced by another one", refs: []string{"storage-secrets/powermax-creds"},
+			copies: []*corev1.Secret{newMirroredSecret("powermax-creds", owner),
+				newMirroredSecret("old-creds", other, owner)},
+			expectedOwners: map[string][]metav1.OwnerReference{"powermax-creds": {owner}, "old-creds": {other}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{source.DeepCopy(), local.DeepCopy()}
+			for _, mirrored := range tt.copies {
+				objects = append(objects, mirrored)
+			}
+			c, err := newFakeClient(objects, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = utils.MirrorSecrets(context.Background(), owner, "test-powermax", tt.refs,
+				[]string{"storage-secrets"}, c, ctrl.Log.WithName("test"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, expectedOwners := range tt.expectedOwners {
+				secret := &corev1.Secret{}
+				err = c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: name}, secret)
+				if name != local.Name && expectedOwners == nil {
+					if !errors.IsNotFound(err) {
+						t.Errorf("expected the copy %s to be deleted, got error %v", name, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("failed to get the secret %s: %v", name, err)
+				}
+				if !reflect.DeepEqual(secret.OwnerReferences, expectedOwners) {
+					t.Errorf("expected the owners %v of %s, got %v", expectedOwners, name, secret.OwnerReferences)
+				}
+				if name == source.Name && resources.IsStringInSlice("storage-secrets/"+name, tt.refs) {
+					if !reflect.DeepEqual(secret.Labels, source.Labels) || !reflect.DeepEqual(secret.Data, source.Data) {
+						t.Errorf("expected the copy to have the labels %v & the data of the source, got labels %v",
+							source.Labels, secret.Labels)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/controllers"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// newVSpherePowerMax - Returns a PowerMax CR with vSphere enabled in the common envs
+func newVSpherePowerMax(configVersion string, commonEnvs, controllerEnvs []corev1.EnvVar) *v1.CSIPowerMax {
+	envs := []corev1.EnvVar{
+		{Name: "X_CSI_VSPHERE_ENABLED", Value: "true"},
+		{Name: "X_CSI_VSPHERE_PORTGROUP", Value: "csi-x-VC-PG"},
+		{Name: "X_CSI_VSPHERE_HOSTNAME", Value: "csi-x-VC-HN"},
+		{Name: "X_CSI_VCENTER_HOST", Value: "vcenter.example.com"},
+	}
+	for _, env := range commonEnvs {
+		replaced := false
+		for i := range envs {
+			if envs[i].Name == env.Name {
+				envs[i] = env
+				replaced = true
+			}
+		}
+		if !replaced {
+			envs = append(envs, env)
+		}
+	}
+	return &v1.CSIPowerMax{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-powermax", Namespace: "test-powermax"},
+		Spec: v1.CSIPowerMaxSpec{
+			Driver: v1.Driver{
+				ConfigVersion: configVersion,
+				Common:        v1.ContainerTemplate{Envs: envs},
+				Controller:    v1.ContainerTemplate{Envs: controllerEnvs},
+			},
+		},
+	}
+}
+
+// newVCenterSecret - Returns a vCenter credentials secret with the given data
+func newVCenterSecret(name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-powermax"},
+		Data:       map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+func TestPowerMaxValidateVSphere(t *testing.T) {
+	credentials := map[string]string{"username": "admin", "password": "secret"}
+	customSecretEnvs := []corev1.EnvVar{
+		{Name: "X_CSI_VCENTER_USERNAME", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "my-vcenter"}, Key: "user"}}},
+		{Name: "X_CSI_VCENTER_PWD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "my-vcenter"}, Key: "pwd"}}},
+	}
+	tests := []struct {
+		name           string
+		configVersion  string
+		commonEnvs     []corev1.EnvVar
+		controllerEnvs []corev1.EnvVar
+		secrets        []runtime.Object
+		// expectedErr is a substring of the expected error (empty if no error is expected)
+		expectedErr string
+		missing     bool
+	}{
+		{
+			name:    "vSphere enabled with valid settings",
+			secrets: []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+		},
+		{
+			name:       "vSphere disabled",
+			commonEnvs: []corev1.EnvVar{{Name: "X_CSI_VSPHERE_ENABLED", Value: "false"}, {Name: "X_CSI_VCENTER_HOST"}},
+		},
+		{
+			name:        "missing vCenter credentials secret",
+			expectedErr: "failed to find the vCenter credentials secret: [vcenter-creds]",
+			missing:     true,
+		},
+		{
+			name:        "missing password key",
+			secrets:     []runtime.Object{newVCenterSecret("vcenter-creds", map[string]string{"username": "admin"})},
+			expectedErr: "data.password: key not found in the vCenter credentials secret vcenter-creds",
+		},
+		{
+			name:        "empty username",
+			secrets:     []runtime.Object{newVCenterSecret("vcenter-creds", map[string]string{"username": "", "password": "secret"})},
+			expectedErr: "data.username: key not found in the vCenter credentials secret vcenter-creds",
+		},
+		{
+			name:        "empty host group",
+			commonEnvs:  []corev1.EnvVar{{Name: "X_CSI_VSPHERE_HOSTNAME", Value: " "}},
+			secrets:     []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+			expectedErr: "spec.driver.common.envs[2]: X_CSI_VSPHERE_HOSTNAME must be set",
+		},
+		{
+			name:           "empty vCenter host for the controller",
+			controllerEnvs: []corev1.EnvVar{{Name: "X_CSI_VCENTER_HOST", Value: ""}},
+			secrets:        []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+			expectedErr:    "spec.driver.controller.envs[0]: X_CSI_VCENTER_HOST must be set",
+		},
+		{
+			name:           "vSphere enabled for the controller only",
+			commonEnvs:     []corev1.EnvVar{{Name: "X_CSI_VSPHERE_ENABLED", Value: "false"}, {Name: "X_CSI_VSPHERE_PORTGROUP"}},
+			controllerEnvs: []corev1.EnvVar{{Name: "X_CSI_VSPHERE_ENABLED", Value: "true"}},
+			secrets:        []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+			expectedErr:    "spec.driver.common.envs[1]: X_CSI_VSPHERE_PORTGROUP must be set",
+		},
+		{
+			name:       "custom vCenter credentials secret",
+			commonEnvs: customSecretEnvs,
+			secrets:    []runtime.Object{newVCenterSecret("my-vcenter", map[string]string{"user": "admin", "pwd": "secret"})},
+		},
+		{
+			name:        "custom vCenter credentials secret with the default keys",
+			commonEnvs:  customSecretEnvs,
+			secrets:     []runtime.Object{newVCenterSecret("my-vcenter", credentials)},
+			expectedErr: "data.user: key not found in the vCenter credentials secret my-vcenter",
+		},
+		{
+			name:        "vCenter username not read from a secret",
+			commonEnvs:  []corev1.EnvVar{{Name: "X_CSI_VCENTER_USERNAME", Value: "admin"}},
+			secrets:     []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+			expectedErr: "spec.driver.common.envs[4]: X_CSI_VCENTER_USERNAME must reference the vCenter credentials secret",
+		},
+		{
+			name:          "config version without a default vCenter credentials secret",
+			configVersion: "v2.5.0",
+			secrets:       []runtime.Object{newVCenterSecret("vcenter-creds", credentials)},
+			expectedErr:   "spec.driver.controller.envs: X_CSI_VCENTER_USERNAME must reference the vCenter credentials secret",
+		},
+		{
+			name:          "custom vCenter credentials secret with a config version without a default",
+			configVersion: "v2.5.0",
+			commonEnvs:    customSecretEnvs,
+			secrets:       []runtime.Object{newVCenterSecret("my-vcenter", map[string]string{"user": "admin", "pwd": "secret"})},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := newFakeClient(test.secrets, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reconciler := &controllers.CSIPowerMaxReconciler{
+				Client: c,
+				Log:    ctrl.Log.WithName("controllers").WithName("CSIPowerMax"),
+			}
+			configVersion := test.configVersion
+			if configVersion == "" {
+				configVersion = "v2.7.0"
+			}
+			driverConfig := &ctrlconfig.Config{
+				ConfigVersion:  configVersion,
+				KubeAPIVersion: "v125",
+				DriverType:     v1.PowerMax,
+				Log:            reconciler.Log,
+				ConfigFileName: "config.yaml",
+			}
+			if err = driverConfig.InitDriverConfig("../driverconfig"); err != nil {
+				t.Fatal(err)
+			}
+			instance := newVSpherePowerMax(configVersion, test.commonEnvs, test.controllerEnvs)
+			err = reconciler.ValidateDriverSpec(context.Background(), instance, driverConfig, reconciler.Log)
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+			}
+			if utils.IsMissingDependency(err) != test.missing {
+				t.Errorf("expected missing dependency %t, got %t", test.missing, utils.IsMissingDependency(err))
+			}
+		})
+	}
+}
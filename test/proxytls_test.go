@@ -0,0 +1,396 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/controllers"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// proxyDNSNames - DNS names of the proxy service in the test namespace
+var proxyDNSNames = []string{
+	"powermax-reverseproxy",
+	"powermax-reverseproxy.test-powermax",
+	"powermax-reverseproxy.test-powermax.svc",
+	"powermax-reverseproxy.test-powermax.svc.cluster.local",
+}
+
+// certificateGVK - GroupVersionKind of the cert-manager Certificates
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// failedCRDQuery - Fails the queries for CRDs
+type failedCRDQuery struct{}
+
+func (failedCRDQuery) shouldFail(method string, obj runtime.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok && method == "Get" && u.GetKind() == "CustomResourceDefinition" {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+// newProxyReconciler - Returns a proxy reconciler using a fake client holding the objects
+func newProxyReconciler(t *testing.T, objects []runtime.Object, injector errorInjector) (
+	*controllers.CSIPowerMaxRevProxyReconciler, *fakeClient) {
+	c, err := newFakeClient(objects, injector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconciler := &controllers.CSIPowerMaxRevProxyReconciler{
+		Log: ctrl.Log.WithName("controllers").WithName("CSIPowerMaxRevProxy"),
+	}
+	reconciler.SetClient(c).SetScheme(scheme.Scheme)
+	return reconciler, c
+}
+
+// reconcileTestProxy - Reconciles the test proxy until its workloads are synced
+func reconcileTestProxy(reconciler *controllers.CSIPowerMaxRevProxyReconciler) {
+	for i := 0; i < 3; i++ {
+		_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "test-powermax", Name: controllers.ReverseProxyName},
+		})
+	}
+}
+
+// newTestProxy - Returns the test proxy with the TLS configuration
+func newTestProxy(t *testing.T, tls *v1.ProxyTLSConfig) *v1.CSIPowerMaxRevProxy {
+	objects := parseTestObjects(t, "testdata/csipowermaxrevproxy/03-self-signed-tls/in-csipowermaxrevproxy.yaml")
+	proxy := objects[0].(*v1.CSIPowerMaxRevProxy)
+	proxy.Spec.TLS = tls
+	return proxy
+}
+
+// getTestProxy - Returns the proxy CR stored in the client
+func getTestProxy(t *testing.T, c *fakeClient) *v1.CSIPowerMaxRevProxy {
+	instance := &v1.CSIPowerMaxRevProxy{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: controllers.ReverseProxyName},
+		instance)
+	if err != nil {
+		t.Fatalf("failed to get the CR: %v", err)
+	}
+	return instance
+}
+
+// getProxySecret - Returns the secret of the proxy stored in the client
+func getProxySecret(t *testing.T, c *fakeClient, name string) *corev1.Secret {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: name}, secret)
+	if err != nil {
+		t.Fatalf("failed to get the secret %s: %v", name, err)
+	}
+	return secret
+}
+
+// getProxyPodTemplateAnnotation - Returns the annotation of the pod template of the proxy
+func getProxyPodTemplateAnnotation(t *testing.T, c *fakeClient, key string) string {
+	deployment := &appsv1.Deployment{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: controllers.ReverseProxyName},
+		deployment)
+	if err != nil {
+		t.Fatalf("failed to get the proxy deployment: %v", err)
+	}
+	return deployment.Spec.Template.Annotations[key]
+}
+
+// parseTestCertificate - Parses a PEM encoded certificate
+func parseTestCertificate(t *testing.T, data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// parseTestCA - Parses the certificate & the private key of the CA stored in the secret
+func parseTestCA(t *testing.T, secret *corev1.Secret) (*x509.Certificate, crypto.Signer) {
+	block, _ := pem.Decode(secret.Data["ca.key"])
+	if block == nil {
+		t.Fatalf("no PEM encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseTestCertificate(t, secret.Data["ca.crt"]), key.(crypto.Signer)
+}
+
+// newTestKeyPair - Returns a PEM encoded certificate created from the template & signed by the parent
+// (self-signed if there is no parent) along with its PEM encoded private key
+func newTestKeyPair(t *testing.T, template, parent *x509.Certificate, parentKey crypto.Signer) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// checkServingCert - Checks that the TLS secret holds a serving certificate of the proxy issued by the CA
+func checkServingCert(t *testing.T, c *fakeClient, duration time.Duration) {
+	caSecret := getProxySecret(t, c, controllers.CASecretName)
+	secret := getProxySecret(t, c, controllers.DefaultTLSSecretName)
+	if secret.Type != corev1.SecretTypeTLS {
+		t.Errorf("expected a secret of type %s, got %s", corev1.SecretTypeTLS, secret.Type)
+	}
+	if !bytes.Equal(secret.Data["ca.crt"], caSecret.Data["ca.crt"]) {
+		t.Errorf("expected the TLS secret to hold the certificate of the CA")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(parseTestCertificate(t, caSecret.Data["ca.crt"]))
+	cert := parseTestCertificate(t, secret.Data[corev1.TLSCertKey])
+	for _, dnsName := range proxyDNSNames {
+		_, err := cert.Verify(x509.VerifyOptions{DNSName: dnsName, Roots: roots})
+		if err != nil {
+			t.Errorf("failed to verify the serving certificate for %s: %v", dnsName, err)
+		}
+	}
+	if expiry := time.Until(cert.NotAfter); expiry > duration || expiry < duration-time.Hour {
+		t.Errorf("expected the serving certificate to expire in %s, got %s", duration, expiry)
+	}
+}
+
+func TestProxySelfSignedTLS(t *testing.T) {
+	proxy := newTestProxy(t, &v1.ProxyTLSConfig{Mode: v1.ProxyTLSModeSelfSigned})
+	reconciler, c := newProxyReconciler(t, []runtime.Object{proxy}, nil)
+	reconcileTestProxy(reconciler)
+
+	caSecret := getProxySecret(t, c, controllers.CASecretName)
+	ca, _ := parseTestCA(t, caSecret)
+	if !ca.IsCA || time.Until(ca.NotAfter) < 9*365*24*time.Hour {
+		t.Errorf("expected a CA valid for 10 years, got a CA expiring at %s", ca.NotAfter)
+	}
+	caCertSecret := getProxySecret(t, c, controllers.CACertSecretName)
+	if len(caCertSecret.Data) != 1 || !bytes.Equal(caCertSecret.Data["ca.crt"], caSecret.Data["ca.crt"]) {
+		t.Errorf("expected the CA certificate secret to only hold the certificate of the CA, got keys %v",
+			reflect.ValueOf(caCertSecret.Data).MapKeys())
+	}
+	checkServingCert(t, c, 365*24*time.Hour)
+	if getProxyPodTemplateAnnotation(t, c, constants.PodTemplateSecretsChecksumKey) == "" {
+		t.Errorf("expected the checksum of the TLS secret on the pod template of the proxy")
+	}
+
+	// The certificates are kept while they are valid
+	servingCert := getProxySecret(t, c, controllers.DefaultTLSSecretName).Data[corev1.TLSCertKey]
+	reconcileTestProxy(reconciler)
+	if !bytes.Equal(getProxySecret(t, c, controllers.CASecretName).Data["ca.crt"], caSecret.Data["ca.crt"]) {
+		t.Errorf("expected the CA to be kept")
+	}
+	if !bytes.Equal(getProxySecret(t, c, controllers.DefaultTLSSecretName).Data[corev1.TLSCertKey], servingCert) {
+		t.Errorf("expected the serving certificate to be kept")
+	}
+}
+
+func TestProxySelfSignedTLSRenewal(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name             string
+		dnsNames         []string
+		expiresIn        time.Duration
+		foreignIssuer    bool
+		caExpiresIn      time.Duration
+		expectedReissued bool
+		expectedNewCA    bool
+	}{
+		{name: "valid serving certificate", dnsNames: proxyDNSNames, expiresIn: 60 * day},
+		{name: "serving certificate about to expire", dnsNames: proxyDNSNames, expiresIn: 10 * day,
+			expectedReissued: true},
+		{name: "serving certificate with a wrong SAN", dnsNames: []string{"wrong.example.com"}, expiresIn: 200 * day,
+			expectedReissued: true},
+		{name: "serving certificate issued by another CA", dnsNames: proxyDNSNames, expiresIn: 200 * day,
+			foreignIssuer: true, expectedReissued: true},
+		{name: "CA expiring before the serving certificate", dnsNames: proxyDNSNames, expiresIn: 60 * day,
+			caExpiresIn: 100 * day, expectedReissued: true, expectedNewCA: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newTestProxy(t, &v1.ProxyTLSConfig{Mode: v1.ProxyTLSModeSelfSigned})
+			reconciler, c := newProxyReconciler(t, []runtime.Object{proxy}, nil)
+			reconcileTestProxy(reconciler)
+			checksum := getProxyPodTemplateAnnotation(t, c, constants.PodTemplateSecretsChecksumKey)
+
+			caSecret := getProxySecret(t, c, controllers.CASecretName)
+			if tt.caExpiresIn != 0 {
+				caCert, caKey := newTestKeyPair(t, &x509.Certificate{
+					Subject:               pkix.Name{CommonName: "short-lived-ca"},
+					NotAfter:              time.Now().Add(tt.caExpiresIn),
+					KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+					BasicConstraintsValid: true,
+					IsCA:                  true,
+				}, nil, nil)
+				caSecret.Data["ca.crt"], caSecret.Data["ca.key"] = caCert, caKey
+				if err := c.Update(context.Background(), caSecret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			parent, parentKey := parseTestCA(t, caSecret)
+			if tt.foreignIssuer {
+				parent, parentKey = nil, nil
+			}
+			certPEM, keyPEM := newTestKeyPair(t, &x509.Certificate{
+				Subject:     pkix.Name{CommonName: tt.dnsNames[0]},
+				DNSNames:    tt.dnsNames,
+				NotAfter:    time.Now().Add(tt.expiresIn),
+				KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}, parent, parentKey)
+			secret := getProxySecret(t, c, controllers.DefaultTLSSecretName)
+			secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey] = certPEM, keyPEM
+			if err := c.Update(context.Background(), secret); err != nil {
+				t.Fatal(err)
+			}
+			reconcileTestProxy(reconciler)
+
+			newCASecret := getProxySecret(t, c, controllers.CASecretName)
+			if newCA := !bytes.Equal(newCASecret.Data["ca.crt"], caSecret.Data["ca.crt"]); newCA != tt.expectedNewCA {
+				t.Errorf("expected a new CA %t, got %t", tt.expectedNewCA, newCA)
+			}
+			caCertSecret := getProxySecret(t, c, controllers.CACertSecretName)
+			if !bytes.Equal(caCertSecret.Data["ca.crt"], newCASecret.Data["ca.crt"]) {
+				t.Errorf("expected the CA certificate secret to be updated with the CA")
+			}
+			servingCert := getProxySecret(t, c, controllers.DefaultTLSSecretName).Data[corev1.TLSCertKey]
+			if reissued := !bytes.Equal(servingCert, certPEM); reissued != tt.expectedReissued {
+				t.Errorf("expected the serving certificate to be reissued %t, got %t", tt.expectedReissued, reissued)
+			}
+			if tt.expectedReissued {
+				checkServingCert(t, c, 365*day)
+			}
+			if getProxyPodTemplateAnnotation(t, c, constants.PodTemplateSecretsChecksumKey) == checksum {
+				t.Errorf("expected the proxy to be rolled out with the new serving certificate")
+			}
+		})
+	}
+}
+
+func TestProxyCertManagerTLS(t *testing.T) {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1",
+		Kind: "CustomResourceDefinition"})
+	crd.SetName("certificates.cert-manager.io")
+	tests := []struct {
+		name                string
+		objects             []runtime.Object
+		injector            errorInjector
+		expectedCertificate bool
+		expectedErr         string
+	}{
+		{name: "cert-manager installed", objects: []runtime.Object{crd}, expectedCertificate: true},
+		{name: "cert-manager not installed",
+			expectedErr: "cert-manager isn't installed: failed to find CRD certificates.cert-manager.io"},
+		{name: "failure to query the CRDs", injector: failedCRDQuery{}, expectedCertificate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newTestProxy(t, &v1.ProxyTLSConfig{
+				Mode:      v1.ProxyTLSModeCertManager,
+				IssuerRef: &v1.CertManagerIssuerRef{Name: "ca-issuer", Kind: "ClusterIssuer"},
+			})
+			reconciler, c := newProxyReconciler(t, append(tt.objects, proxy), tt.injector)
+			reconcileTestProxy(reconciler)
+
+			status := getTestProxy(t, c).Status
+			if tt.expectedErr != "" {
+				if status.State != constants.InvalidConfig || !strings.Contains(status.LastUpdate.ErrorMessage, tt.expectedErr) {
+					t.Errorf("expected state %s with error %q, got state %s with error %q", constants.InvalidConfig,
+						tt.expectedErr, status.State, status.LastUpdate.ErrorMessage)
+				}
+			} else if status.State == constants.InvalidConfig {
+				t.Errorf("unexpected invalid config: %s", status.LastUpdate.ErrorMessage)
+			}
+
+			certificate := &unstructured.Unstructured{}
+			certificate.SetGroupVersionKind(certificateGVK)
+			err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax",
+				Name: controllers.ReverseProxyName}, certificate)
+			if !tt.expectedCertificate {
+				if !errors.IsNotFound(err) {
+					t.Errorf("expected no Certificate, got error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get the Certificate: %v", err)
+			}
+			dnsNames := make([]interface{}, 0)
+			for _, dnsName := range proxyDNSNames {
+				dnsNames = append(dnsNames, dnsName)
+			}
+			expectedSpec := map[string]interface{}{
+				"secretName":  controllers.DefaultTLSSecretName,
+				"commonName":  "powermax-reverseproxy.test-powermax.svc",
+				"dnsNames":    dnsNames,
+				"duration":    "8760h0m0s",
+				"renewBefore": "720h0m0s",
+				"usages":      []interface{}{"digital signature", "key encipherment", "server auth"},
+				"issuerRef": map[string]interface{}{
+					"name":  "ca-issuer",
+					"kind":  "ClusterIssuer",
+					"group": "cert-manager.io",
+				},
+			}
+			if !reflect.DeepEqual(certificate.Object["spec"], expectedSpec) {
+				t.Errorf("expected the Certificate spec\n%v\ngot\n%v", expectedSpec, certificate.Object["spec"])
+			}
+			owners := certificate.GetOwnerReferences()
+			if len(owners) != 1 || owners[0].Kind != "CSIPowerMaxRevProxy" || owners[0].Controller == nil ||
+				!*owners[0].Controller {
+				t.Errorf("expected the Certificate to be controlled by the proxy, got owners %v", owners)
+			}
+		})
+	}
+}
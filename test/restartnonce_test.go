@@ -0,0 +1,87 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getPowerMaxPodTemplateAnnotations - Returns the annotations of the pod templates of the controller & node plugins
+// of the test PowerMax CR
+func getPowerMaxPodTemplateAnnotations(t *testing.T, c *fakeClient) (map[string]string, map[string]string) {
+	controller := &appsv1.Deployment{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "powermax-controller"},
+		controller)
+	if err != nil {
+		t.Fatalf("failed to get the controller deployment: %v", err)
+	}
+	node := &appsv1.DaemonSet{}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "powermax-node"}, node)
+	if err != nil {
+		t.Fatalf("failed to get the node daemonset: %v", err)
+	}
+	return controller.Spec.Template.Annotations, node.Spec.Template.Annotations
+}
+
+func TestRestartNonce(t *testing.T) {
+	dir := "testdata/csipowermax/10-restart-nonce/"
+	objects := parseTestObjects(t, dir+"in-csipowermax.yaml", dir+"in-csipowermax-secret.yaml",
+		dir+"in-vcenter-secret.yaml")
+	reconciler, c, _ := newPowerMaxReconciler(t, objects...)
+	reconcileTestPowerMax(reconciler)
+	reconcileTestPowerMax(reconciler)
+	controller, node := getPowerMaxPodTemplateAnnotations(t, c)
+	checksum := controller[constants.PodTemplateChecksumKey]
+	if checksum == "" {
+		t.Fatalf("expected the checksum of the pod template of the controller plugin, got %v", controller)
+	}
+
+	// Restart the driver pods
+	instance := &v1.CSIPowerMax{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "test-powermax"}, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := "2026-01-02T00:00:00Z"
+	instance.Spec.Driver.RestartNonce = nonce
+	if err = c.Update(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestPowerMax(reconciler)
+	reconcileTestPowerMax(reconciler)
+	controller, node = getPowerMaxPodTemplateAnnotations(t, c)
+	if restartedAt := controller[constants.PodTemplateRestartedAtKey]; restartedAt != nonce {
+		t.Errorf("expected the controller plugin to be restarted at %s, got %q", nonce, restartedAt)
+	}
+	if controller[constants.PodTemplateChecksumKey] == checksum {
+		t.Errorf("expected the checksum of the pod template of the controller plugin to change")
+	}
+	if restartedAt := node[constants.PodTemplateRestartedAtKey]; restartedAt != nonce {
+		t.Errorf("expected the node plugin to be restarted at %s, got %q", nonce, restartedAt)
+	}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "test-powermax"}, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Status.RestartNonce != nonce {
+		t.Errorf("expected the restart nonce %s in the status, got %q", nonce, instance.Status.RestartNonce)
+	}
+}
@@ -0,0 +1,247 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/controllers"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// revisionHistoryName - Name of the configmap with the revision history of the test Isilon CR
+const revisionHistoryName = "test-isilon-revision-history"
+
+// newRevisionHistory - Returns a revision history configmap with the given revisions
+func newRevisionHistory(t *testing.T, revisions ...utils.DriverRevision) *corev1.ConfigMap {
+	data := make(map[string]string)
+	for _, revision := range revisions {
+		revisionJSON, err := json.Marshal(revision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[fmt.Sprintf("revision-%d", revision.Revision)] = string(revisionJSON)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: revisionHistoryName, Namespace: "test-isilon"},
+		Data:       data,
+	}
+}
+
+// getRevisionHistory - Returns the revisions stored in the revision history configmap, keyed by revision
+func getRevisionHistory(t *testing.T, c *fakeClient) map[int64]utils.DriverRevision {
+	found := &corev1.ConfigMap{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: revisionHistoryName, Namespace: "test-isilon"}, found)
+	if err != nil {
+		t.Fatalf("failed to get the revision history: %v", err)
+	}
+	revisions := make(map[int64]utils.DriverRevision)
+	for key, value := range found.Data {
+		var revision utils.DriverRevision
+		if err := json.Unmarshal([]byte(value), &revision); err != nil {
+			t.Fatalf("failed to parse %s: %v", key, err)
+		}
+		revisions[revision.Revision] = revision
+	}
+	return revisions
+}
+
+// runningWorkloads - Marks the controller & the node workloads as ready when they are synced
+// (the fake client doesn't keep the status of the workloads across updates)
+type runningWorkloads struct{}
+
+func (runningWorkloads) shouldFail(method string, obj runtime.Object) error {
+	if method != "Create" && method != "Update" {
+		return nil
+	}
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		workload.Status.ReadyReplicas = *workload.Spec.Replicas
+		workload.Status.AvailableReplicas = *workload.Spec.Replicas
+		workload.Status.UpdatedReplicas = *workload.Spec.Replicas
+	case *appsv1.DaemonSet:
+		workload.Status.DesiredNumberScheduled = 1
+		workload.Status.NumberReady = 1
+		workload.Status.NumberAvailable = 1
+		workload.Status.UpdatedNumberScheduled = 1
+	}
+	return nil
+}
+
+// runIsilon - Syncs the simple Isilon deployment with running pods & returns the reconciler & the client
+func runIsilon(t *testing.T, objects ...runtime.Object) (*controllers.CSIIsilonReconciler, *fakeClient) {
+	objects = append(parseSimpleIsilon(t), objects...)
+	objects = append(objects,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "isilon-controller-0", Namespace: "test-isilon",
+				Labels: map[string]string{"app": "isilon-controller"}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "isilon-node-0", Namespace: "test-isilon",
+				Labels: map[string]string{"app": "isilon-node"}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			}},
+		},
+	)
+	reconciler, c, _ := newIsilonReconciler(t, "../driverconfig", "v125", runningWorkloads{}, objects...)
+	// Update the spec & sync the driver
+	reconcileTestIsilon(reconciler)
+	reconcileTestIsilon(reconciler)
+	if state := getTestIsilon(t, c).Status.State; state != constants.Running {
+		t.Fatalf("expected state Running, got %s", state)
+	}
+	return reconciler, c
+}
+
+// parseSimpleIsilon - Parses the CR & the credentials secret of the simple Isilon deployment
+func parseSimpleIsilon(t *testing.T) []runtime.Object {
+	return parseTestObjects(t, "testdata/csiisilon/01-simple-deployment/in-csiisilon.yaml",
+		"testdata/csiisilon/01-simple-deployment/in-csiisilon-secret.yaml")
+}
+
+func TestRecordDriverRevision(t *testing.T) {
+	reconciler, c := runIsilon(t)
+	// The same driver spec is only recorded once
+	reconcileTestIsilon(reconciler)
+	instance := getTestIsilon(t, c)
+	revisions := getRevisionHistory(t, c)
+	if len(revisions) != 1 {
+		t.Fatalf("expected 1 revision, got %d", len(revisions))
+	}
+	revision := revisions[1]
+	if instance.Status.Revision != 1 {
+		t.Errorf("expected revision 1 in the status, got %d", instance.Status.Revision)
+	}
+	if driverHash := utils.HashDriver(instance); revision.DriverHash != driverHash {
+		t.Errorf("expected driver hash %d, got %d", driverHash, revision.DriverHash)
+	}
+	if revision.ConfigVersion != "v2.7.0" {
+		t.Errorf("expected config version v2.7.0, got %s", revision.ConfigVersion)
+	}
+	if revision.Images["driver"] != "dellemc/csi-isilon:v2.7.0" {
+		t.Errorf("expected driver image dellemc/csi-isilon:v2.7.0, got %s", revision.Images["driver"])
+	}
+	if !reflect.DeepEqual(revision.Driver, instance.Spec.Driver) {
+		t.Errorf("expected the driver spec of the CR in the revision")
+	}
+}
+
+func TestRecordDriverRevisionPrunesHistory(t *testing.T) {
+	history := make([]utils.DriverRevision, 0)
+	for i := int64(1); i <= constants.DriverRevisionHistoryLimit; i++ {
+		history = append(history, utils.DriverRevision{Revision: i, DriverHash: uint64(i), ConfigVersion: "v2.6.0"})
+	}
+	_, c := runIsilon(t, newRevisionHistory(t, history...))
+	revisions := getRevisionHistory(t, c)
+	if len(revisions) != constants.DriverRevisionHistoryLimit {
+		t.Fatalf("expected %d revisions, got %d", constants.DriverRevisionHistoryLimit, len(revisions))
+	}
+	if _, ok := revisions[1]; ok {
+		t.Errorf("expected the oldest revision to be pruned")
+	}
+	latest := int64(constants.DriverRevisionHistoryLimit + 1)
+	if revisions[latest].ConfigVersion != "v2.7.0" {
+		t.Errorf("expected revision %d with config version v2.7.0, got %+v", latest, revisions[latest])
+	}
+	if revision := getTestIsilon(t, c).Status.Revision; revision != latest {
+		t.Errorf("expected revision %d in the status, got %d", latest, revision)
+	}
+}
+
+func TestApplyRollback(t *testing.T) {
+	objects := parseSimpleIsilon(t)
+	previous := objects[0].(*v1.CSIIsilon).Spec.Driver.DeepCopy()
+	previous.ConfigVersion = "v2.6.0"
+	previous.Common.Image = "dellemc/csi-isilon:v2.6.0"
+	previous.UpgradePolicy = v1.UpgradePolicyAutoMinor
+	followingChannel := previous.DeepCopy()
+	followingChannel.ConfigVersion = "stable"
+	history := newRevisionHistory(t,
+		utils.DriverRevision{Revision: 1, DriverHash: 1, ConfigVersion: "v2.6.0", Driver: *previous},
+		utils.DriverRevision{Revision: 2, DriverHash: 2, ConfigVersion: "v2.6.0", Driver: *followingChannel},
+	)
+	tests := []struct {
+		name                  string
+		revision              string
+		expectedStatus        metav1.ConditionStatus
+		expectedConfigVersion string
+		expectedImage         string
+		expectedPolicy        v1.UpgradePolicy
+	}{
+		{name: "rollback", revision: "1", expectedStatus: metav1.ConditionTrue, expectedConfigVersion: "v2.6.0",
+			expectedImage: "dellemc/csi-isilon:v2.6.0", expectedPolicy: v1.UpgradePolicyManual},
+		{name: "rollback of a driver which followed a channel", revision: "2", expectedStatus: metav1.ConditionTrue,
+			expectedConfigVersion: "v2.6.0", expectedImage: "dellemc/csi-isilon:v2.6.0",
+			expectedPolicy: v1.UpgradePolicyManual},
+		{name: "unknown revision", revision: "3", expectedStatus: metav1.ConditionFalse,
+			expectedConfigVersion: "v2.7.0", expectedImage: "dellemc/csi-isilon:v2.7.0"},
+		{name: "invalid revision", revision: "latest", expectedStatus: metav1.ConditionFalse,
+			expectedConfigVersion: "v2.7.0", expectedImage: "dellemc/csi-isilon:v2.7.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseSimpleIsilon(t)
+			instance := objects[0].(*v1.CSIIsilon)
+			instance.Annotations = map[string]string{
+				"storage.dell.com/CSIDriverConfigVersion": "v2.7.0",
+				"storage.dell.com/rollback-to":            tt.revision,
+			}
+			instance.Status = v1.DriverStatus{State: constants.Running, DriverHash: 3}
+			c, recorder := reconcileIsilon(t, "../driverconfig", "v125", append(objects, history.DeepCopy())...)
+			instance = getTestIsilon(t, c)
+			if _, ok := instance.Annotations["storage.dell.com/rollback-to"]; ok {
+				t.Errorf("expected the rollback annotation to be removed")
+			}
+			condition := meta.FindStatusCondition(instance.Status.Conditions, constants.ConditionRollback)
+			if condition == nil || condition.Status != tt.expectedStatus {
+				t.Fatalf("expected Rollback condition %s, got %+v", tt.expectedStatus, condition)
+			}
+			if len(recorder.Events) == 0 {
+				t.Errorf("expected a rollback event")
+			}
+			driver := instance.Spec.Driver
+			if driver.ConfigVersion != tt.expectedConfigVersion || driver.Common.Image != tt.expectedImage {
+				t.Errorf("expected config version %s & image %s, got %s & %s", tt.expectedConfigVersion,
+					tt.expectedImage, driver.ConfigVersion, driver.Common.Image)
+			}
+			if driver.UpgradePolicy != tt.expectedPolicy {
+				t.Errorf("expected upgrade policy %q, got %q", tt.expectedPolicy, driver.UpgradePolicy)
+			}
+			if tt.expectedStatus != metav1.ConditionTrue {
+				return
+			}
+			if configVersion := instance.Annotations["storage.dell.com/CSIDriverConfigVersion"]; configVersion != "v2.6.0" {
+				t.Errorf("expected config version annotation v2.6.0, got %s", configVersion)
+			}
+			if instance.Status.DriverHash != 0 {
+				t.Errorf("expected the driver hash to be reset, got %d", instance.Status.DriverHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// pendingCanary - Marks the workloads as ready except for the canary daemonset, which has no updated pods
+type pendingCanary struct{}
+
+func (pendingCanary) shouldFail(method string, obj runtime.Object) error {
+	_ = runningWorkloads{}.shouldFail(method, obj)
+	if ds, ok := obj.(*appsv1.DaemonSet); ok && strings.HasSuffix(ds.Name, "-canary") {
+		ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 1}
+	}
+	return nil
+}
+
+func TestRolloutStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		canary           *v1.NodeCanary
+		injector         errorInjector
+		expectedProgress int32
+		expectedCanary   *v1.NodeWorkloadRolloutStatus
+		expectedDone     bool
+	}{
+		{name: "no canary", injector: runningWorkloads{}, expectedProgress: 100, expectedDone: true},
+		{name: "updated canary", canary: &v1.NodeCanary{
+			NodeSelector: map[string]string{"canary": "true"}, Image: "dellemc/csi-isilon:v2.7.1",
+		}, injector: runningWorkloads{}, expectedProgress: 100, expectedDone: true,
+			expectedCanary: &v1.NodeWorkloadRolloutStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1,
+				NumberAvailable: 1}},
+		{name: "pending canary", canary: &v1.NodeCanary{
+			NodeSelector: map[string]string{"canary": "true"}, Image: "dellemc/csi-isilon:v2.7.1",
+		}, injector: pendingCanary{}, expectedProgress: 66,
+			expectedCanary: &v1.NodeWorkloadRolloutStatus{DesiredNumberScheduled: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, c := runIsilon(t)
+			c.errorInjector = tt.injector
+			instance := getTestIsilon(t, c)
+			previousHash := instance.Status.DriverHash
+			instance.Spec.Driver.Node.Canary = tt.canary
+			instance.Spec.Driver.RestartNonce = "rollout"
+			if err := c.Update(context.Background(), instance); err != nil {
+				t.Fatal(err)
+			}
+			reconcileTestIsilon(reconciler)
+			reconcileTestIsilon(reconciler)
+			status := getTestIsilon(t, c).Status
+			rollout := status.Rollout
+			if rollout == nil {
+				t.Fatalf("expected a rollout status")
+			}
+			if rollout.TargetDriverHash != status.DriverHash || status.DriverHash == previousHash {
+				t.Errorf("expected the rollout of the driver hash %d, got %d", status.DriverHash, rollout.TargetDriverHash)
+			}
+			if rollout.Progress != tt.expectedProgress {
+				t.Errorf("expected progress %d, got %d", tt.expectedProgress, rollout.Progress)
+			}
+			expectedCurrentHash := previousHash
+			if tt.expectedDone {
+				expectedCurrentHash = status.DriverHash
+			}
+			if rollout.CurrentDriverHash != expectedCurrentHash {
+				t.Errorf("expected current driver hash %d, got %d", expectedCurrentHash, rollout.CurrentDriverHash)
+			}
+			if (rollout.Canary == nil) != (tt.expectedCanary == nil) ||
+				(tt.expectedCanary != nil && *rollout.Canary != *tt.expectedCanary) {
+				t.Errorf("expected canary rollout status %+v, got %+v", tt.expectedCanary, rollout.Canary)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dell/dell-csi-operator/controllers"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getIsilonPodTemplateAnnotations - Returns the annotations of the pod templates of the controller & node plugins
+// of the test Isilon CR
+func getIsilonPodTemplateAnnotations(t *testing.T, c *fakeClient) (map[string]string, map[string]string) {
+	controller := &appsv1.Deployment{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "isilon-controller"},
+		controller)
+	if err != nil {
+		t.Fatalf("failed to get the controller deployment: %v", err)
+	}
+	node := &appsv1.DaemonSet{}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "isilon-node"}, node)
+	if err != nil {
+		t.Fatalf("failed to get the node daemonset: %v", err)
+	}
+	return controller.Spec.Template.Annotations, node.Spec.Template.Annotations
+}
+
+// runSettledIsilon - Deploys the test Isilon CR & reconciles it till the driver spec doesn't change anymore
+func runSettledIsilon(t *testing.T, disableSecretRollout bool) (*controllers.CSIIsilonReconciler, *fakeClient) {
+	reconciler, c := runIsilon(t)
+	if disableSecretRollout {
+		instance := getTestIsilon(t, c)
+		instance.Spec.Driver.DisableSecretRollout = true
+		if err := c.Update(context.Background(), instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileTestIsilon(reconciler)
+	reconcileTestIsilon(reconciler)
+	if state := getTestIsilon(t, c).Status.State; state != constants.Running {
+		t.Fatalf("expected state Running, got %s", state)
+	}
+	return reconciler, c
+}
+
+// rotateIsilonPassword - Rotates the password of the PowerScale clusters in the credentials secret
+func rotateIsilonPassword(t *testing.T, c *fakeClient) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "isilon-creds"}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setIsilonClusters(t, c, bytes.ReplaceAll(secret.Data["config"], []byte(`password: "password"`),
+		[]byte(`password: "rotated"`)))
+}
+
+func TestSecretRotationRollsOutDriverPods(t *testing.T) {
+	reconciler, c := runSettledIsilon(t, false)
+	controller, node := getIsilonPodTemplateAnnotations(t, c)
+	if controller[constants.PodTemplateSecretsChecksumKey] == "" || node[constants.PodTemplateSecretsChecksumKey] == "" {
+		t.Fatalf("expected the checksum of the secrets on the pod templates, got %v & %v", controller, node)
+	}
+
+	rotateIsilonPassword(t, c)
+	reconcileTestIsilon(reconciler)
+	rotatedController, rotatedNode := getIsilonPodTemplateAnnotations(t, c)
+	if rotatedController[constants.PodTemplateSecretsChecksumKey] == controller[constants.PodTemplateSecretsChecksumKey] {
+		t.Errorf("expected the controller plugin to be rolled out with the rotated secret")
+	}
+	if rotatedNode[constants.PodTemplateSecretsChecksumKey] == node[constants.PodTemplateSecretsChecksumKey] {
+		t.Errorf("expected the node plugin to be rolled out with the rotated secret")
+	}
+	if state := getTestIsilon(t, c).Status.State; state == constants.InvalidConfig {
+		t.Errorf("expected the driver to be synced, got state %s", state)
+	}
+}
+
+func TestSecretRotationWithDisabledRollout(t *testing.T) {
+	reconciler, c := runSettledIsilon(t, true)
+	controller, node := getIsilonPodTemplateAnnotations(t, c)
+
+	rotateIsilonPassword(t, c)
+	reconcileTestIsilon(reconciler)
+	rotatedController, rotatedNode := getIsilonPodTemplateAnnotations(t, c)
+	if !reflect.DeepEqual(rotatedController, controller) {
+		t.Errorf("expected the pod template of the controller plugin to be kept, got %v instead of %v",
+			rotatedController, controller)
+	}
+	if !reflect.DeepEqual(rotatedNode, node) {
+		t.Errorf("expected the pod template of the node plugin to be kept, got %v instead of %v", rotatedNode, node)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// isilonClustersWithTwoDefaults - PowerScale clusters which are both marked as the default one
+const isilonClustersWithTwoDefaults = `isilonClusters:
+  - clusterName: "cluster1"
+    username: "admin"
+    password: "password"
+    endpoint: "10.0.0.1"
+    isDefault: true
+  - clusterName: "cluster2"
+    username: "admin"
+    password: "password"
+    endpoint: "10.0.0.2"
+    isDefault: true
+`
+
+// setIsilonClusters - Replaces the PowerScale clusters in the credentials secret of the test Isilon CR
+func setIsilonClusters(t *testing.T, c *fakeClient, config []byte) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "isilon-creds"}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Data["config"] = config
+	if err = c.Update(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorageArraysClearedOnInvalidConfig(t *testing.T) {
+	reconciler, c := runIsilon(t)
+	if arrays := getTestIsilon(t, c).Status.StorageArrays; len(arrays) != 2 {
+		t.Fatalf("expected 2 storage arrays, got %v", arrays)
+	}
+	validConfig := parseSimpleIsilon(t)[1].(*corev1.Secret).Data["config"]
+
+	// The config secret is made invalid while the driver is running
+	setIsilonClusters(t, c, []byte(isilonClustersWithTwoDefaults))
+	reconcileTestIsilon(reconciler)
+	status := getTestIsilon(t, c).Status
+	if status.State != constants.InvalidConfig {
+		t.Fatalf("expected state InvalidConfig, got %s", status.State)
+	}
+	expected := "isilonClusters: exactly one cluster must have isDefault: true, found 2"
+	if !strings.Contains(status.LastUpdate.ErrorMessage, expected) {
+		t.Errorf("expected the error message to contain %q, got %q", expected, status.LastUpdate.ErrorMessage)
+	}
+	if status.StorageArrays != nil {
+		t.Errorf("expected the storage arrays to be cleared, got %v", status.StorageArrays)
+	}
+
+	// The config secret is fixed & the driver is restarted
+	setIsilonClusters(t, c, validConfig)
+	instance := getTestIsilon(t, c)
+	instance.Spec.Driver.RestartNonce = "1"
+	if err := c.Update(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestIsilon(reconciler)
+	if arrays := getTestIsilon(t, c).Status.StorageArrays; len(arrays) != 2 {
+		t.Errorf("expected 2 storage arrays, got %v", arrays)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestProxyTrustBundleRollout(t *testing.T) {
+	dir := "testdata/csipowermaxrevproxy/04-trusted-ca-bundle/"
+	objects := parseTestObjects(t, dir+"in-csipowermaxrevproxy.yaml", dir+"in-csipowermaxrevorixytlssecret.yaml")
+	bundle := parseTestObjects(t, "testdata/csipowermax/04-trust-bundle/in-configmap-trusted-ca-bundle.yaml")[0]
+	reconciler, c := newProxyReconciler(t, objects, nil)
+	reconcileTestProxy(reconciler)
+	emptyChecksum := getProxyPodTemplateAnnotation(t, c, constants.PodTemplateTrustBundleChecksumKey)
+	if emptyChecksum == "" {
+		t.Fatalf("expected the checksum of the empty trust bundle")
+	}
+
+	// OpenShift injects the trusted CA bundle in the ConfigMap created by the operator
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-powermax", Name: "trusted-ca-bundle"}, configMap)
+	if err != nil {
+		t.Fatalf("failed to get the trust bundle: %v", err)
+	}
+	configMap.Data = bundle.(*corev1.ConfigMap).Data
+	if err = c.Update(context.Background(), configMap); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTestProxy(reconciler)
+	if checksum := getProxyPodTemplateAnnotation(t, c, constants.PodTemplateTrustBundleChecksumKey); checksum == emptyChecksum {
+		t.Errorf("expected the proxy to be rolled out with the injected trust bundle")
+	}
+}
+
+func TestSyncTrustedCABundleConfigMap(t *testing.T) {
+	bundle := &v1.TrustBundle{
+		InjectTrustedCABundle: true,
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "trusted-ca-bundle"},
+			Key:                  utils.TrustedCABundleKey,
+		},
+	}
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "trusted-ca-bundle", Namespace: "test-isilon"},
+		Data:       map[string]string{utils.TrustedCABundleKey: "bundle"},
+	}
+	c, err := newFakeClient([]runtime.Object{existing}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := metav1.OwnerReference{APIVersion: "storage.dell.com/v1", Kind: "CSIIsilon", Name: "test-isilon"}
+	err = utils.SyncTrustedCABundleConfigMap(context.Background(), bundle, owner, "test-isilon", c,
+		ctrl.Log.WithName("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configMap := &corev1.ConfigMap{}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "trusted-ca-bundle"}, configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Labels[utils.TrustedCABundleInjectionLabel] != "true" {
+		t.Errorf("expected the existing ConfigMap to be labeled for the injection, got labels %v", configMap.Labels)
+	}
+	if configMap.Data[utils.TrustedCABundleKey] != "bundle" {
+		t.Errorf("expected the data of the existing ConfigMap to be kept, got %v", configMap.Data)
+	}
+
+	bundle.ConfigMapKeyRef.Key = "ca.crt"
+	if err := utils.ValidateTrustBundle(bundle); err == nil {
+		t.Errorf("expected injectTrustedCABundle to require the key %s", utils.TrustedCABundleKey)
+	}
+}
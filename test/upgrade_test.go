@@ -0,0 +1,378 @@
+/*
+ Copyright © 2022 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+      http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/dell/dell-csi-operator/api/v1"
+	"github.com/dell/dell-csi-operator/controllers"
+	operatorconfig "github.com/dell/dell-csi-operator/pkg/config"
+	"github.com/dell/dell-csi-operator/pkg/constants"
+	"github.com/dell/dell-csi-operator/pkg/ctrlconfig"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// upgradeTestConfig - Operator config with patch releases to exercise the upgrade policies
+const upgradeTestConfig = `supportedK8sVersions:
+  - v125
+drivers:
+  - name: isilon
+    upgradePaths:
+      - from: v2.5.0
+        to:
+          - v2.5.1
+          - v2.6.0
+      - from: v2.5.1
+        to:
+          - v2.6.0
+      - from: v2.6.0
+        to:
+          - v2.7.0
+    channels:
+      - name: stable
+        configVersions:
+          - v2.5.0
+    configVersions:
+      - configVersion: v2.7.0
+        supportedVersions:
+          - version: v125
+      - configVersion: v2.6.0
+        supportedVersions:
+          - version: v125
+      - configVersion: v2.5.2
+        supportedVersions:
+          - version: v125
+      - configVersion: v2.5.1
+        supportedVersions:
+          - version: v125
+      - configVersion: v2.5.0
+        supportedVersions:
+          - version: v125
+`
+
+// writeUpgradeTestConfig - Writes the upgrade test config to a temporary directory & returns the directory
+func writeUpgradeTestConfig(t *testing.T) string {
+	configDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(upgradeTestConfig), 0600)
+	if err != nil {
+		t.Fatalf("failed to write the operator config: %v", err)
+	}
+	return configDir
+}
+
+// newTestIsilon - Returns an Isilon CR with the given config version & upgrade policy
+func newTestIsilon(configVersion string, policy v1.UpgradePolicy, state v1.DriverState) *v1.CSIIsilon {
+	return &v1.CSIIsilon{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-isilon", Namespace: "test-isilon"},
+		Spec: v1.CSIIsilonSpec{
+			Driver: v1.Driver{
+				ConfigVersion: configVersion,
+				UpgradePolicy: policy,
+			},
+		},
+		Status: v1.DriverStatus{State: state},
+	}
+}
+
+// newIsilonReconciler - Returns an Isilon reconciler with a fake client holding the objects
+func newIsilonReconciler(t *testing.T, configDir string, k8sVersion v1.K8sVersion, injector errorInjector,
+	objects ...runtime.Object) (*controllers.CSIIsilonReconciler, *fakeClient, *record.FakeRecorder) {
+	if err := v1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("cannot add to scheme: %v", err)
+	}
+	c, err := newFakeClient(objects, injector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(20)
+	reconciler := &controllers.CSIIsilonReconciler{
+		Log:      ctrl.Log.WithName("controllers").WithName("CSIIsilon"),
+		Recorder: recorder,
+	}
+	reconciler.SetClient(c)
+	reconciler.SetScheme(scheme.Scheme)
+	reconciler.SetConfig(operatorconfig.Config{
+		ConfigDirectory:      configDir,
+		ConfigFile:           "config.yaml",
+		KubeAPIServerVersion: k8sVersion,
+		EnabledDrivers:       []v1.DriverType{v1.Isilon},
+		RetryCount:           1,
+	})
+	return reconciler, c, recorder
+}
+
+// reconcileTestIsilon - Runs one reconcile of the test Isilon CR
+func reconcileTestIsilon(reconciler *controllers.CSIIsilonReconciler) {
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "test-isilon", Name: "test-isilon"},
+	})
+}
+
+// reconcileIsilon - Runs one reconcile of the Isilon CR in the objects & returns the client & the event recorder
+func reconcileIsilon(t *testing.T, configDir string, k8sVersion v1.K8sVersion,
+	objects ...runtime.Object) (*fakeClient, *record.FakeRecorder) {
+	reconciler, c, recorder := newIsilonReconciler(t, configDir, k8sVersion, nil, objects...)
+	reconcileTestIsilon(reconciler)
+	return c, recorder
+}
+
+// parseTestObjects - Parses the objects in the given files
+func parseTestObjects(t *testing.T, paths ...string) []runtime.Object {
+	if err := v1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("cannot add to scheme: %v", err)
+	}
+	objects := make([]runtime.Object, 0, len(paths))
+	for _, path := range paths {
+		obj, err := parseFile(path)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", path, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// getTestIsilon - Returns the Isilon CR stored in the client
+func getTestIsilon(t *testing.T, c *fakeClient) *v1.CSIIsilon {
+	instance := &v1.CSIIsilon{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: "test-isilon", Name: "test-isilon"}, instance)
+	if err != nil {
+		t.Fatalf("failed to get the CR: %v", err)
+	}
+	return instance
+}
+
+func TestGetAvailableUpgrades(t *testing.T) {
+	opConfig, err := ctrlconfig.ReadOpConfig("../driverconfig", "config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read the operator config: %v", err)
+	}
+	tests := []struct {
+		name          string
+		configVersion string
+		k8sVersion    v1.K8sVersion
+		expected      []string
+	}{
+		{name: "all newer config versions", configVersion: "v2.5.0", k8sVersion: "v125",
+			expected: []string{"v2.6.0", "v2.7.0"}},
+		{name: "only config versions supported on the K8s version", configVersion: "v2.5.0", k8sVersion: "v123",
+			expected: []string{"v2.6.0"}},
+		{name: "newest config version", configVersion: "v2.7.0", k8sVersion: "v125"},
+		{name: "latest channel", configVersion: "latest", k8sVersion: "v125"},
+		{name: "stable channel", configVersion: "stable", k8sVersion: "v125", expected: []string{"v2.7.0"}},
+		{name: "unknown config version", configVersion: "next", k8sVersion: "v125"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrades := opConfig.GetAvailableUpgrades(v1.Isilon, tt.configVersion, tt.k8sVersion)
+			if !reflect.DeepEqual(upgrades, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, upgrades)
+			}
+		})
+	}
+}
+
+func TestGetNextUpgrade(t *testing.T) {
+	upgrades := []string{"v2.5.1", "v2.5.2", "v2.6.0", "v3.0.0"}
+	tests := []struct {
+		name          string
+		configVersion string
+		upgrades      []string
+		policy        v1.UpgradePolicy
+		expected      string
+	}{
+		{name: "manual", configVersion: "v2.5.0", upgrades: upgrades, policy: v1.UpgradePolicyManual},
+		{name: "auto patch moves one patch release", configVersion: "v2.5.0", upgrades: upgrades,
+			policy: v1.UpgradePolicyAutoPatch, expected: "v2.5.1"},
+		{name: "auto patch doesn't move to a minor release", configVersion: "v2.5.2", upgrades: []string{"v2.6.0"},
+			policy: v1.UpgradePolicyAutoPatch},
+		{name: "auto minor moves one release", configVersion: "v2.5.0", upgrades: upgrades,
+			policy: v1.UpgradePolicyAutoMinor, expected: "v2.5.1"},
+		{name: "auto minor moves to a minor release", configVersion: "v2.5.2", upgrades: []string{"v2.6.0", "v3.0.0"},
+			policy: v1.UpgradePolicyAutoMinor, expected: "v2.6.0"},
+		{name: "auto minor doesn't move to a major release", configVersion: "v2.6.0", upgrades: []string{"v3.0.0"},
+			policy: v1.UpgradePolicyAutoMinor},
+		{name: "no upgrades", configVersion: "v2.6.0", policy: v1.UpgradePolicyAutoMinor},
+		{name: "channel alias", configVersion: "latest", upgrades: upgrades, policy: v1.UpgradePolicyAutoMinor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := ctrlconfig.GetNextUpgrade(tt.configVersion, tt.upgrades, tt.policy)
+			if next != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestApplyUpgradePolicy(t *testing.T) {
+	configDir := writeUpgradeTestConfig(t)
+	tests := []struct {
+		name          string
+		configVersion string
+		policy        v1.UpgradePolicy
+		state         v1.DriverState
+		expected      string
+		upgraded      bool
+	}{
+		{name: "manual", configVersion: "v2.5.0", policy: v1.UpgradePolicyManual, state: constants.Running,
+			expected: "v2.5.0"},
+		{name: "no policy", configVersion: "v2.5.0", state: constants.Running, expected: "v2.5.0"},
+		{name: "auto patch", configVersion: "v2.5.0", policy: v1.UpgradePolicyAutoPatch, state: constants.Running,
+			expected: "v2.5.1", upgraded: true},
+		{name: "auto patch without an upgrade path", configVersion: "v2.5.1", policy: v1.UpgradePolicyAutoPatch,
+			state: constants.Running, expected: "v2.5.1"},
+		{name: "auto minor", configVersion: "v2.5.1", policy: v1.UpgradePolicyAutoMinor, state: constants.Running,
+			expected: "v2.6.0", upgraded: true},
+		{name: "auto minor on a driver which isn't running", configVersion: "v2.5.1",
+			policy: v1.UpgradePolicyAutoMinor, state: constants.Failed, expected: "v2.5.1"},
+		{name: "auto minor with a channel alias", configVersion: "stable", policy: v1.UpgradePolicyAutoMinor,
+			state: constants.Running, expected: "stable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, recorder := reconcileIsilon(t, configDir, "v125", newTestIsilon(tt.configVersion, tt.policy, tt.state))
+			instance := getTestIsilon(t, c)
+			if instance.Spec.Driver.ConfigVersion != tt.expected {
+				t.Errorf("expected config version %s, got %s", tt.expected, instance.Spec.Driver.ConfigVersion)
+			}
+			upgraded := false
+			for len(recorder.Events) != 0 {
+				if event := <-recorder.Events; event == "Normal "+constants.ReasonConfigVersionUpgraded+" Upgraded config version from "+
+					tt.configVersion+" to "+tt.expected+" as per "+string(tt.policy)+" upgrade policy" {
+					upgraded = true
+				}
+			}
+			if upgraded != tt.upgraded {
+				t.Errorf("expected upgrade event %t, got %t", tt.upgraded, upgraded)
+			}
+		})
+	}
+}
+
+func TestApplyUpgradePolicyInvalid(t *testing.T) {
+	configDir := writeUpgradeTestConfig(t)
+	c, _ := reconcileIsilon(t, configDir, "v125", newTestIsilon("v2.5.0", "Always", constants.Running))
+	instance := getTestIsilon(t, c)
+	if instance.Spec.Driver.ConfigVersion != "v2.5.0" {
+		t.Errorf("expected config version v2.5.0, got %s", instance.Spec.Driver.ConfigVersion)
+	}
+	if instance.Status.State != constants.InvalidConfig {
+		t.Errorf("expected state InvalidConfig, got %s", instance.Status.State)
+	}
+}
+
+func TestIsUpgradeAllowed(t *testing.T) {
+	opConfig, err := ctrlconfig.ReadOpConfig("../driverconfig", "config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read the operator config: %v", err)
+	}
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		expectedErr string
+	}{
+		{name: "same config version", from: "v2.6.0", to: "v2.6.0"},
+		{name: "declared upgrade path", from: "v2.6.0", to: "v2.7.0"},
+		{name: "undeclared upgrade path", from: "v2.5.0", to: "v2.7.0",
+			expectedErr: "upgrade of config version from v2.5.0 to v2.7.0 is not allowed. Allowed upgrades: [v2.6.0]"},
+		{name: "downgrade", from: "v2.7.0", to: "v2.6.0",
+			expectedErr: "downgrade of config version from v2.7.0 to v2.6.0 is not allowed"},
+		{name: "invalid config version", from: "v2.6.0", to: "latest",
+			expectedErr: "failed to parse config version latest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := opConfig.IsUpgradeAllowed(v1.Isilon, tt.from, tt.to)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigVersionChange(t *testing.T) {
+	tests := []struct {
+		name            string
+		previousVersion string
+		configVersion   string
+		override        bool
+		// expectedErr is a substring of the expected error message in the status (empty if no error is expected)
+		expectedErr string
+	}{
+		{name: "declared upgrade path", previousVersion: "v2.6.0", configVersion: "v2.7.0"},
+		{name: "undeclared upgrade path", previousVersion: "v2.5.0", configVersion: "v2.7.0",
+			expectedErr: "upgrade of config version from v2.5.0 to v2.7.0 is not allowed"},
+		{name: "downgrade", previousVersion: "v2.7.0", configVersion: "v2.6.0",
+			expectedErr: "downgrade of config version from v2.7.0 to v2.6.0 is not allowed"},
+		{name: "undeclared upgrade path with the override", previousVersion: "v2.5.0", configVersion: "v2.7.0",
+			override: true},
+		{name: "downgrade with the override", previousVersion: "v2.7.0", configVersion: "v2.6.0", override: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseTestObjects(t, "testdata/csiisilon/01-simple-deployment/in-csiisilon.yaml",
+				"testdata/csiisilon/01-simple-deployment/in-csiisilon-secret.yaml")
+			instance := objects[0].(*v1.CSIIsilon)
+			instance.Spec.Driver.ConfigVersion = tt.configVersion
+			instance.Status = v1.DriverStatus{State: constants.Running, DriverHash: 1}
+			instance.Annotations = map[string]string{"storage.dell.com/CSIDriverConfigVersion": tt.previousVersion}
+			if tt.override {
+				instance.Annotations["storage.dell.com/override-upgrade-path"] = "true"
+			}
+			c, _ := reconcileIsilon(t, "../driverconfig", "v125", objects...)
+			instance = getTestIsilon(t, c)
+			if tt.expectedErr != "" {
+				if instance.Status.State != constants.InvalidConfig ||
+					!strings.Contains(instance.Status.LastUpdate.ErrorMessage, tt.expectedErr) {
+					t.Errorf("expected state InvalidConfig with error %q, got %s with error %q", tt.expectedErr,
+						instance.Status.State, instance.Status.LastUpdate.ErrorMessage)
+				}
+				if configVersion := instance.Annotations["storage.dell.com/CSIDriverConfigVersion"]; configVersion != tt.previousVersion {
+					t.Errorf("expected config version annotation %s, got %s", tt.previousVersion, configVersion)
+				}
+				return
+			}
+			if instance.Status.State == constants.InvalidConfig {
+				t.Errorf("unexpected error: %s", instance.Status.LastUpdate.ErrorMessage)
+			}
+			if configVersion := instance.Annotations["storage.dell.com/CSIDriverConfigVersion"]; configVersion != tt.configVersion {
+				t.Errorf("expected config version annotation %s, got %s", tt.configVersion, configVersion)
+			}
+			if _, ok := instance.Annotations["storage.dell.com/override-upgrade-path"]; ok {
+				t.Errorf("expected the override annotation to be removed")
+			}
+		})
+	}
+}